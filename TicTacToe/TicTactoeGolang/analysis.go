@@ -0,0 +1,239 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// Analyze returns the best move for toMove on b and its score under perfect
+// play: +1 for a forced win, 0 for a draw, -1 for a forced loss. It errors if
+// the game on b is already over. A move that wins on the spot is preferred to
+// slower forced wins.
+func Analyze(b *Board, toMove Mark) (bestMove int, score float64, err error) {
+	if _, won := b.Winner(); won || b.IsFull() {
+		return -1, 0, fmt.Errorf("analyze: %w", ErrGameOver)
+	}
+	if mv, ok := findWinningMove(b, toMove); ok {
+		return mv, 1, nil
+	}
+	return NewMinimax("analysis").searchBest(context.Background(), b, toMove)
+}
+
+// PositionValue returns the game-theoretic value of b for toMove, the side to
+// play: +1 if it can force a win, -1 if the opponent can, 0 if perfect play
+// draws. A finished board is valued by its result. Perfect play is searched
+// to the end, so this is only practical on small boards.
+func PositionValue(b *Board, toMove Mark) int {
+	ai := NewMinimax("value")
+	ai.me = toMove
+	ai.cache = make(map[string]ttEntry)
+	return int(ai.negamax(context.Background(), b.Clone(), toMove, -1, math.Inf(-1), math.Inf(1)))
+}
+
+// CountTerminals returns the number of distinct games that can be played out
+// from b with toMove to play: the leaves of the game tree, where someone has
+// won or the board is full. From the empty 3x3 board that is 255,168. A
+// finished board counts as one.
+func CountTerminals(b *Board, toMove Mark) int {
+	nb := b.Clone()
+	nb.maxHistory = 0 // every move must stay unmakeable
+	return countTerminals(nb, toMove)
+}
+
+// countTerminals is CountTerminals, playing and taking back moves on b.
+func countTerminals(b *Board, toMove Mark) int {
+	if _, won := b.Winner(); won || b.IsFull() {
+		return 1
+	}
+	n := 0
+	for _, mv := range b.AvailableMoves() {
+		_ = b.MakeMove(mv, toMove)
+		n += countTerminals(b, b.switchMark(toMove))
+		_ = b.UnmakeMove(mv)
+	}
+	return n
+}
+
+// analyzeInput reads a 3x3 board layout in ParseBoard's format from r,
+// works out whose turn it is and writes the best move and its score to w.
+func analyzeInput(r io.Reader, w io.Writer) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	b, err := ParseBoardStrict(string(data))
+	if err != nil {
+		return err
+	}
+	toMove, _ := b.InferTurn() // checked by ParseBoardStrict
+	mv, score, err := Analyze(b, toMove)
+	if err != nil {
+		return err
+	}
+	outcome := "draw"
+	switch {
+	case score > 0:
+		outcome = fmt.Sprintf("%c wins", toMove)
+	case score < 0:
+		outcome = fmt.Sprintf("%c wins", b.switchMark(toMove))
+	}
+	row, col := b.RowCol(mv)
+	fmt.Fprintf(w, "%c to move: best move %d (row %d, col %d), score %g (%s)\n", toMove, mv, row, col, score, outcome)
+	return nil
+}
+
+// WinningMoves returns every move, in index order, that wins by force for
+// toMove: its minimax value under perfect play is +1. It returns nil if the
+// game on b is already over or no move wins.
+func WinningMoves(b *Board, toMove Mark) []int {
+	if _, won := b.Winner(); won {
+		return nil
+	}
+	ai := NewMinimax("analysis")
+	ai.me = toMove
+	ai.cache = make(map[string]ttEntry)
+	var wins []int
+	for _, mv := range b.AvailableMoves() {
+		nb := b.Clone()
+		_ = nb.MakeMove(mv, toMove)
+		score := -ai.negamax(context.Background(), nb, b.switchMark(toMove), -1, math.Inf(-1), math.Inf(1))
+		if score == 1 {
+			wins = append(wins, mv)
+		}
+	}
+	return wins
+}
+
+// CountForks returns how many free cells would give m a fork: two or more
+// cells where m would then win, so the opponent cannot block them all.
+func CountForks(b *Board, m Mark) int {
+	n := 0
+	for _, mv := range b.AvailableMoves() {
+		if createsFork(b, mv, m) {
+			n++
+		}
+	}
+	return n
+}
+
+// createsFork reports whether m playing idx leaves at least two threats.
+func createsFork(b *Board, idx int, m Mark) bool {
+	nb := b.Clone()
+	if err := nb.MakeMove(idx, m); err != nil {
+		return false
+	}
+	return len(nb.Threats(m)) >= 2
+}
+
+// BestDefensiveMove returns the free cell where m does the most damage to the
+// opponent's prospects, without searching. A cell where the opponent would
+// complete a line comes first; otherwise each line through the cell that is
+// still open to the opponent counts with the heuristic's lineWeight for the
+// opponent marks on it. Ties go to the lowest index. It returns false if no
+// cell is free.
+func BestDefensiveMove(b *Board, m Mark) (int, bool) {
+	opp := b.switchMark(m)
+	if threats := b.Threats(opp); len(threats) > 0 {
+		return threats[0], true
+	}
+	gain := make([]float64, len(b.cells))
+	for _, line := range b.lines {
+		nOpp, blocked := 0, false
+		for _, idx := range line {
+			switch b.At(idx) {
+			case opp:
+				nOpp++
+			case m:
+				blocked = true
+			}
+		}
+		if blocked {
+			continue // already dead for the opponent
+		}
+		w := lineWeight(nOpp, len(line))
+		for _, idx := range line {
+			gain[idx] += w
+		}
+	}
+	best := -1
+	for _, idx := range b.AvailableMoves() {
+		if best == -1 || gain[idx] > gain[best] {
+			best = idx
+		}
+	}
+	return best, best != -1
+}
+
+// TrainingSample is a position labeled with its best move, as produced by
+// GenerateTrainingData.
+type TrainingSample struct {
+	Board  *Board
+	ToMove Mark
+	Move   int     // the best move, as chosen by Analyze
+	Value  float64 // the position's value for ToMove, as scored by Analyze
+}
+
+// GenerateTrainingData returns n labeled 3x3 positions for training a model.
+// They are collected by playing games where each move is, at random, either
+// the best one or any legal one, so the samples cover reachable positions
+// beyond those of perfect play. Positions may repeat.
+func GenerateTrainingData(n int) []TrainingSample {
+	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+	samples := make([]TrainingSample, 0, n)
+	for len(samples) < n {
+		b, toMove := NewBoard(), X
+		for len(samples) < n {
+			mv, score, err := Analyze(b, toMove)
+			if err != nil {
+				break // game over
+			}
+			samples = append(samples, TrainingSample{Board: b.Clone(), ToMove: toMove, Move: mv, Value: score})
+			if rng.Intn(2) == 0 {
+				moves := b.AvailableMoves()
+				mv = moves[rng.Intn(len(moves))]
+			}
+			_ = b.MakeMove(mv, toMove)
+			toMove = b.switchMark(toMove)
+		}
+	}
+	return samples
+}
+
+// ReachableBoards returns every distinct 3x3 position reachable from the empty
+// board in exactly plies moves, X first, without passing through a finished
+// game. With reduceSymmetry, positions that are rotations or reflections of
+// each other count once and are returned in canonical form. The boards have
+// no move history.
+func ReachableBoards(plies int, reduceSymmetry bool) []*Board {
+	start := NewBoard()
+	level := []*Board{start}
+	toMove := X
+	for ply := 0; ply < plies; ply++ {
+		seen := make(map[string]bool)
+		var next []*Board
+		for _, b := range level {
+			if _, over := b.Winner(); over {
+				continue
+			}
+			for _, mv := range b.AvailableMoves() {
+				nb := b.Clone()
+				_ = nb.MakeMove(mv, toMove)
+				nb.history = nil
+				if reduceSymmetry {
+					nb = nb.CanonicalForm()
+				}
+				if key := nb.hash(toMove); !seen[key] {
+					seen[key] = true
+					next = append(next, nb)
+				}
+			}
+		}
+		level = next
+		toMove = start.switchMark(toMove)
+	}
+	return level
+}
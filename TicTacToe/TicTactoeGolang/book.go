@@ -0,0 +1,48 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// OpeningBook maps positions, keyed by Board.Hash, to a recommended move.
+// MinimaxAI plays a booked move instead of searching.
+type OpeningBook map[uint64]int
+
+// Add books move for the position on b.
+func (ob OpeningBook) Add(b *Board, move int) { ob[b.Hash()] = move }
+
+// Lookup returns the booked move for b if there is one and its cell is free.
+func (ob OpeningBook) Lookup(b *Board) (int, bool) {
+	mv, ok := ob[b.Hash()]
+	if !ok || b.CanMove(mv) != nil {
+		return -1, false
+	}
+	return mv, true
+}
+
+// Save writes the book to path as a JSON object from hash to move.
+func (ob OpeningBook) Save(path string) error {
+	data, err := json.MarshalIndent(ob, "", "  ")
+	if err != nil {
+		return fmt.Errorf("save opening book: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("save opening book: %w", err)
+	}
+	return nil
+}
+
+// LoadOpeningBook reads a book written by Save.
+func LoadOpeningBook(path string) (OpeningBook, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("load opening book: %w", err)
+	}
+	ob := make(OpeningBook)
+	if err := json.Unmarshal(data, &ob); err != nil {
+		return nil, fmt.Errorf("load opening book %s: invalid JSON: %w", path, err)
+	}
+	return ob, nil
+}
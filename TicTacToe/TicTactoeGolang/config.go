@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// config holds the command-line options.
+type config struct {
+	Mode    string // hvh, hvai, aivai, hvrand or analyze
+	AIDepth int    // search depth for AI players, negative for the default
+	Size    int    // board is Size x Size, Size in a row wins
+	Seed    int64  // seed for random players, 0 to seed from the clock
+	Clear   bool   // clear the screen before each turn
+
+	// Messages holds all the program's text, for games and players alike;
+	// EnglishMessages if nil. It has no flag.
+	Messages *Messages
+}
+
+var modes = map[string]string{
+	"hvh":    "human vs human",
+	"hvai":   "human vs AI",
+	"aivai":  "AI vs AI",
+	"hvrand": "human vs random player",
+
+	// analyze reads a board from stdin and prints the best move instead
+	// of playing.
+	"analyze": "position analysis",
+}
+
+// parseConfig parses command-line arguments (without the program name).
+func parseConfig(args []string) (config, error) {
+	var cfg config
+	fs := flag.NewFlagSet("tictactoe", flag.ContinueOnError)
+	fs.StringVar(&cfg.Mode, "mode", "hvai", "game mode: hvh, hvai, aivai, hvrand or analyze")
+	fs.IntVar(&cfg.AIDepth, "ai-depth", -1, "AI search depth in plies, negative for perfect play on 3x3 and a time limit on larger boards")
+	fs.IntVar(&cfg.Size, "size", 3, "board size; that many in a row wins")
+	fs.Int64Var(&cfg.Seed, "seed", 0, "seed for the random player, 0 to seed from the clock")
+	fs.BoolVar(&cfg.Clear, "clear", false, "clear the screen before each turn (terminals only)")
+	if err := fs.Parse(args); err != nil {
+		return config{}, err
+	}
+	if fs.NArg() > 0 {
+		return config{}, fmt.Errorf("unexpected argument %q", fs.Arg(0))
+	}
+	if _, ok := modes[cfg.Mode]; !ok {
+		return config{}, fmt.Errorf("invalid mode %q: want hvh, hvai, aivai, hvrand or analyze", cfg.Mode)
+	}
+	if cfg.Size < 3 {
+		return config{}, fmt.Errorf("invalid size %d: must be at least 3", cfg.Size)
+	}
+	return cfg, nil
+}
+
+// aiMoveBudget is the time per move of the default AI on boards larger than
+// 3x3, which are too big to search to the end.
+const aiMoveBudget = time.Second
+
+// newAI returns an AI player honoring AIDepth. Without a depth it plays
+// perfectly on 3x3 and deepens within aiMoveBudget on larger boards.
+func (cfg config) newAI(name string) Player {
+	switch {
+	case cfg.AIDepth >= 0:
+		return NewMinimaxDepth(name, cfg.AIDepth)
+	case cfg.Size > 3:
+		return NewIterativeDeepening(name, aiMoveBudget)
+	}
+	return NewMinimax(name)
+}
+
+// newHuman returns a human reading from in whose hints come from the same kind
+// of AI as newAI builds.
+func (cfg config) newHuman(name string, in *bufio.Reader) *Human {
+	h := NewHumanIO(name, in, os.Stdout)
+	h.Hinter = cfg.newAI("Hint")
+	h.Messages = cfg.Messages
+	return h
+}
+
+// players builds the X and O players for one game. Humans read from in; in
+// hvai mode the human is first asked which side to play.
+func (cfg config) players(in *bufio.Reader) (px, po Player) {
+	switch cfg.Mode {
+	case "hvh":
+		return cfg.newHuman("Player 1", in), cfg.newHuman("Player 2", in)
+	case "aivai":
+		return cfg.newAI("AI 1"), cfg.newAI("AI 2")
+	case "hvrand":
+		seed := cfg.Seed
+		if seed == 0 {
+			seed = time.Now().UnixNano()
+		}
+		return cfg.newHuman("You", in), NewRandomSeeded("Random", seed)
+	}
+	fmt.Print(messagesOr(cfg.Messages).ChooseSide)
+	side, _ := in.ReadString('\n')
+	mark := X
+	if strings.TrimSpace(strings.ToLower(side)) == "o" {
+		mark = O
+	}
+	return seatPlayers(cfg.newHuman("You", in), cfg.newAI("AI"), mark)
+}
+
+// parsePlayAgain interprets the answer to the play-again prompt for a session
+// on a size x size board: "y" plays again at that size, "play N" plays on an
+// N x N board instead, and anything else quits. An invalid size is an error
+// so the caller can ask again.
+func parsePlayAgain(answer string, size int) (newSize int, again bool, err error) {
+	fields := strings.Fields(strings.ToLower(answer))
+	switch {
+	case len(fields) == 1 && (fields[0] == "y" || fields[0] == "yes"):
+		return size, true, nil
+	case len(fields) == 2 && fields[0] == "play":
+		n, err := strconv.Atoi(fields[1])
+		if err != nil || n < 3 {
+			return size, false, fmt.Errorf("invalid size %q: must be a number, at least 3", fields[1])
+		}
+		return n, true, nil
+	}
+	return size, false, nil
+}
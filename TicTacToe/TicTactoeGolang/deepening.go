@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"time"
+)
+
+// IterativeDeepeningAI searches with MinimaxAI at depth 0, 1, 2, ... until
+// its time budget runs out, and plays the best move of the deepest search it
+// completed. This plays as well as the budget allows on boards too large to
+// search to the end. Like MinimaxAI it plays two-player games only.
+type IterativeDeepeningAI struct {
+	name   string
+	Budget time.Duration // time allowed per move
+}
+
+func NewIterativeDeepening(name string, budget time.Duration) *IterativeDeepeningAI {
+	return &IterativeDeepeningAI{name: name, Budget: budget}
+}
+
+func (id *IterativeDeepeningAI) Name() string { return id.name }
+
+// Move always returns a legal move while the game is not over: if not even
+// the depth-0 search finishes in time it falls back to the most promising cell
+// by move order. On a finished board it returns ErrGameOver, and with more
+// than two players ErrTwoPlayersOnly.
+func (id *IterativeDeepeningAI) Move(ctx context.Context, b *Board, mark Mark) (int, error) {
+	if _, won := b.Winner(); won || b.IsFull() {
+		return -1, ErrGameOver
+	}
+	if len(b.marks) != 2 {
+		return -1, ErrTwoPlayersOnly
+	}
+	moves := b.orderedMoves()
+	ctx, cancel := context.WithTimeout(ctx, id.Budget)
+	defer cancel()
+	best := moves[0]
+	ai := NewMinimaxDepth(id.name, 0)
+	// Searching deeper than the number of moves left cannot change the result.
+	for depth := 0; depth < len(moves); depth++ {
+		ai.MaxDepth = depth
+		mv, err := ai.Move(ctx, b, mark)
+		if err != nil || ctx.Err() != nil {
+			break // an interrupted search is not trusted
+		}
+		best = mv
+	}
+	return best, nil
+}
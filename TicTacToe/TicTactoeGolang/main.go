@@ -2,14 +2,24 @@ package main
 
 import (
 	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
 	"errors"
+	"flag"
 	"fmt"
+	"hash/fnv"
+	"io"
+	"log/slog"
 	"math"
 	"math/rand"
 	"os"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
+	"unicode"
 )
 
 // Marks
@@ -21,31 +31,166 @@ const (
 	O     Mark = 'O'
 )
 
-var winLines = [8][3]int{
-	{0, 1, 2}, {3, 4, 5}, {6, 7, 8}, // rows
-	{0, 3, 6}, {1, 4, 7}, {2, 5, 8}, // cols
-	{0, 4, 8}, {2, 4, 6}, // diags
+// winLinesFor returns every run of winLen consecutive cells on a rows x cols
+// board: rows, columns, diagonals and anti-diagonals.
+func winLinesFor(rows, cols, winLen int) [][]int {
+	var lines [][]int
+	dirs := [4][2]int{{0, 1}, {1, 0}, {1, 1}, {1, -1}} // row, col, diag, anti-diag
+	for r := 0; r < rows; r++ {
+		for c := 0; c < cols; c++ {
+			for _, d := range dirs {
+				endR, endC := r+d[0]*(winLen-1), c+d[1]*(winLen-1)
+				if endR < 0 || endR >= rows || endC < 0 || endC >= cols {
+					continue
+				}
+				line := make([]int, winLen)
+				for k := 0; k < winLen; k++ {
+					line[k] = (r+d[0]*k)*cols + c + d[1]*k
+				}
+				lines = append(lines, line)
+			}
+		}
+	}
+	return lines
 }
 
-// Board encapsulates tic-tac-toe board (3x3)
+// Board encapsulates a rows x cols tic-tac-toe board where winLen marks in a
+// row (horizontally, vertically or diagonally) win.
 type Board struct {
-	cells [9]Mark
+	rows   int
+	cols   int
+	winLen int
+	cells  []Mark
+	lines  [][]int
+	order  []int  // cell indices, most win lines through them first
+	marks  []Mark // the players' marks in turn order, first mover first
+
+	history []Move // moves applied via MakeMove, oldest first
+	redo    []Move // moves taken back via Undo, most recent last
+
+	maxHistory int // most moves kept in history, 0 for no limit
+}
+
+// Move is a single placement of a mark on the board.
+type Move struct {
+	Index int
+	Mark  Mark
 }
 
+// NewBoard returns the classic 3x3 board with three in a row to win.
 func NewBoard() *Board {
-	b := &Board{}
+	return NewBoardN(3, 3)
+}
+
+// NewBoardN returns a size x size board on which winLen marks in a row win.
+// It panics if size < 1 or winLen is not in [1, size].
+func NewBoardN(size, winLen int) *Board {
+	if size < 1 || winLen < 1 || winLen > size {
+		panic(fmt.Sprintf("invalid board: size %d, win length %d", size, winLen))
+	}
+	return NewBoardMN(size, size, winLen)
+}
+
+// NewBoardMN returns a board of rows by cols cells on which winLen marks in a
+// row win. It panics if either dimension is below 1 or winLen is not in
+// [1, max(rows, cols)].
+func NewBoardMN(rows, cols, winLen int) *Board {
+	if rows < 1 || cols < 1 || winLen < 1 || winLen > max(rows, cols) {
+		panic(fmt.Sprintf("invalid board: %dx%d, win length %d", rows, cols, winLen))
+	}
+	b := &Board{
+		rows:   rows,
+		cols:   cols,
+		winLen: winLen,
+		cells:  make([]Mark, rows*cols),
+		lines:  winLinesFor(rows, cols, winLen),
+		marks:  []Mark{X, O},
+	}
 	for i := range b.cells {
 		b.cells[i] = Empty
 	}
+	b.order = moveOrder(len(b.cells), b.lines)
 	return b
 }
 
+// moveOrder ranks cell indices by how many of lines pass through them, most
+// first, ties by index. On 3x3 that is center, corners, then edges.
+func moveOrder(cells int, lines [][]int) []int {
+	count := make([]int, cells)
+	for _, line := range lines {
+		for _, idx := range line {
+			count[idx]++
+		}
+	}
+	order := make([]int, cells)
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return count[order[i]] > count[order[j]]
+	})
+	return order
+}
+
+// Size returns the number of rows, which on a square board is also the
+// number of columns.
+func (b *Board) Size() int { return b.rows }
+
+// Rows returns the number of rows of the board.
+func (b *Board) Rows() int { return b.rows }
+
+// Cols returns the number of columns of the board.
+func (b *Board) Cols() int { return b.cols }
+
+// RowCol returns the zero-based row and column of cell idx. Cells are
+// numbered row by row from the top left, so index 0 is (0, 0), index Cols()-1
+// is (0, Cols()-1) and index Cols() is (1, 0). It panics if idx is off the
+// board.
+func (b *Board) RowCol(idx int) (int, int) {
+	if idx < 0 || idx >= len(b.cells) {
+		panic(fmt.Sprintf("index %d out of range for %dx%d board", idx, b.rows, b.cols))
+	}
+	return idx / b.cols, idx % b.cols
+}
+
+// Index is the inverse of RowCol. It panics if row or col is off the board.
+func (b *Board) Index(row, col int) int {
+	if row < 0 || row >= b.rows || col < 0 || col >= b.cols {
+		panic(fmt.Sprintf("cell (%d, %d) out of range for %dx%d board", row, col, b.rows, b.cols))
+	}
+	return row*b.cols + col
+}
+
+// WinLen returns the number of marks in a row needed to win.
+func (b *Board) WinLen() int { return b.winLen }
+
 func (b *Board) Clone() *Board {
-	nb := &Board{}
-	copy(nb.cells[:], b.cells[:])
+	nb := &Board{
+		rows:   b.rows,
+		cols:   b.cols,
+		winLen: b.winLen,
+		cells:  make([]Mark, len(b.cells)),
+		lines:  b.lines, // never mutated, safe to share
+		order:  b.order,
+		marks:  b.marks,
+
+		maxHistory: b.maxHistory,
+	}
+	copy(nb.cells, b.cells)
+	nb.history = append([]Move(nil), b.history...)
+	nb.redo = append([]Move(nil), b.redo...)
 	return nb
 }
 
+// Each calls fn for every cell in index order, that is row by row, with the
+// cell's index, row, column and mark.
+func (b *Board) Each(fn func(idx int, row, col int, m Mark)) {
+	for idx, m := range b.cells {
+		row, col := b.RowCol(idx)
+		fn(idx, row, col, m)
+	}
+}
+
 func (b *Board) IsFull() bool {
 	for _, c := range b.cells {
 		if c == Empty {
@@ -65,256 +210,1918 @@ func (b *Board) AvailableMoves() []int {
 	return moves
 }
 
-func (b *Board) MakeMove(idx int, m Mark) error {
-	if idx < 0 || idx >= 9 {
-		return errors.New("index out of bounds")
+// LegalMoveCount returns the number of free cells, len(AvailableMoves())
+// without building the slice.
+func (b *Board) LegalMoveCount() int { return b.Count(Empty) }
+
+// orderedMoves is AvailableMoves in search order: cells on more win lines
+// first. Trying the strongest moves early lets alpha-beta establish a good
+// bound quickly, so weaker siblings searched afterwards are cut off sooner.
+func (b *Board) orderedMoves() []int {
+	moves := make([]int, 0, len(b.cells))
+	for _, idx := range b.order {
+		if b.cells[idx] == Empty {
+			moves = append(moves, idx)
+		}
+	}
+	return moves
+}
+
+// Errors for illegal moves and bad move input. They are usually wrapped with
+// details such as the cell index, so test for them with errors.Is.
+var (
+	ErrOutOfBounds   = errors.New("index out of bounds")
+	ErrCellOccupied  = errors.New("cell occupied")
+	ErrNoMoves       = errors.New("no moves available")
+	ErrInvalidNumber = errors.New("invalid number")
+)
+
+// ErrGameOver is returned by AI players asked to move on a board that is
+// already won or full.
+var ErrGameOver = errors.New("game is already over")
+
+// ErrTwoPlayersOnly is returned by MinimaxAI and IterativeDeepeningAI on a
+// board with more than two players: their search assumes a single opponent.
+var ErrTwoPlayersOnly = errors.New("AI supports only two-player games")
+
+// CanMove reports whether a mark may be placed at idx, without placing it:
+// it returns nil, or the error MakeMove would (ErrOutOfBounds or
+// ErrCellOccupied).
+func (b *Board) CanMove(idx int) error {
+	if idx < 0 || idx >= len(b.cells) {
+		return fmt.Errorf("%w: %d not in 0-%d", ErrOutOfBounds, idx, len(b.cells)-1)
 	}
 	if b.cells[idx] != Empty {
-		return errors.New("cell occupied")
+		return fmt.Errorf("%w: %d", ErrCellOccupied, idx)
+	}
+	return nil
+}
+
+// At returns the mark on cell idx, Empty if the cell is free. It panics if
+// idx is off the board.
+func (b *Board) At(idx int) Mark {
+	if idx < 0 || idx >= len(b.cells) {
+		panic(fmt.Sprintf("index %d out of range for %dx%d board", idx, b.rows, b.cols))
+	}
+	return b.cells[idx]
+}
+
+// Set puts m, a player's mark or Empty, on cell idx whatever it held, for
+// setting up a position. Unlike MakeMove it may overwrite a mark and it
+// records no history. It fails with ErrOutOfBounds if idx is off the board.
+func (b *Board) Set(idx int, m Mark) error {
+	if idx < 0 || idx >= len(b.cells) {
+		return fmt.Errorf("%w: %d not in 0-%d", ErrOutOfBounds, idx, len(b.cells)-1)
+	}
+	if m != Empty && !b.isMark(m) {
+		return fmt.Errorf("invalid mark %q", m)
+	}
+	b.cells[idx] = m
+	return nil
+}
+
+// MakeMove places m at idx. It fails as CanMove does, or if m is not one of
+// the players' marks.
+func (b *Board) MakeMove(idx int, m Mark) error {
+	if err := b.CanMove(idx); err != nil {
+		return err
+	}
+	if !b.isMark(m) {
+		return fmt.Errorf("invalid mark %q", m)
 	}
 	b.cells[idx] = m
+	b.history = append(b.history, Move{Index: idx, Mark: m})
+	b.redo = nil
+	b.trimHistory()
+	return nil
+}
+
+// SetMaxHistory limits the move history to the n most recent moves, so the
+// oldest are forgotten and can no longer be undone. Zero means no limit.
+func (b *Board) SetMaxHistory(n int) {
+	b.maxHistory = n
+	b.trimHistory()
+}
+
+// trimHistory drops the oldest moves beyond maxHistory.
+func (b *Board) trimHistory() {
+	if b.maxHistory > 0 && len(b.history) > b.maxHistory {
+		b.history = append(b.history[:0], b.history[len(b.history)-b.maxHistory:]...)
+	}
+}
+
+// Undo takes back the most recent move, leaving its cell empty. The move can
+// be re-applied with Redo until another MakeMove is made.
+func (b *Board) Undo() error {
+	if len(b.history) == 0 {
+		return errors.New("nothing to undo")
+	}
+	last := b.history[len(b.history)-1]
+	b.history = b.history[:len(b.history)-1]
+	b.cells[last.Index] = Empty
+	b.redo = append(b.redo, last)
+	return nil
+}
+
+// UnmakeMove takes back the move just made at idx by MakeMove. Unlike Undo
+// it does not make the move available to Redo, so a search can play and take
+// back moves on a single board instead of copying it for each one. It fails
+// if idx is not the last move in the history.
+func (b *Board) UnmakeMove(idx int) error {
+	n := len(b.history)
+	if n == 0 || b.history[n-1].Index != idx {
+		return fmt.Errorf("unmake move %d: not the last move", idx)
+	}
+	b.history = b.history[:n-1]
+	b.cells[idx] = Empty
+	return nil
+}
+
+// UndoTo takes back moves until only the first ply moves of the history are
+// left, as if Undo were called repeatedly, so they can be redone in turn. It
+// fails if ply is negative or more than the moves in the history.
+func (b *Board) UndoTo(ply int) error {
+	if ply < 0 || ply > len(b.history) {
+		return fmt.Errorf("undo to ply %d: history has %d moves", ply, len(b.history))
+	}
+	for len(b.history) > ply {
+		_ = b.Undo()
+	}
+	return nil
+}
+
+// Redo re-applies the most recently undone move.
+func (b *Board) Redo() error {
+	if len(b.redo) == 0 {
+		return errors.New("nothing to redo")
+	}
+	next := b.redo[len(b.redo)-1]
+	b.redo = b.redo[:len(b.redo)-1]
+	b.cells[next.Index] = next.Mark
+	b.history = append(b.history, next)
+	b.trimHistory()
 	return nil
 }
 
+// LastMove returns the index of the most recent move still on the board.
+// Undo and Redo move it back and forth along the history.
+func (b *Board) LastMove() (int, bool) {
+	if len(b.history) == 0 {
+		return -1, false
+	}
+	return b.history[len(b.history)-1].Index, true
+}
+
 func (b *Board) Winner() (Mark, bool) {
-	for _, line := range winLines {
-		a, b1, c := b.cells[line[0]], b.cells[line[1]], b.cells[line[2]]
-		if a != Empty && a == b1 && a == c {
-			return a, true
+	m, _, ok := b.WinnerLine()
+	return m, ok
+}
+
+// WinnerLine is like Winner but also returns the indices of the cells that
+// form the winning line, in board order, e.g. [0 1 2] for the top row.
+// Every run of winLen cells in any direction is checked, from the list built
+// once per board size by winLinesFor; each check stops at the first cell
+// that breaks the run, so a scan stays cheap even on large boards (60 runs on
+// 7x7 with five in a row).
+func (b *Board) WinnerLine() (Mark, []int, bool) {
+	for _, line := range b.lines {
+		if m := b.lineOwner(line); m != Empty {
+			return m, append([]int(nil), line...), true
 		}
 	}
-	return Empty, false
+	return Empty, nil, false
 }
 
-func (b *Board) String() string {
-	var sb strings.Builder
-	for r := 0; r < 3; r++ {
-		for c := 0; c < 3; c++ {
-			sb.WriteRune(rune(b.cells[r*3+c]))
-			if c < 2 {
-				sb.WriteString(" | ")
+// Validate reports why the position could not occur in a legal game where
+// the players move in turn, the first mark (X by default) first: the mark
+// counts must agree with InferTurn, at most one side may have completed a
+// line, and nobody may have moved after the game was won.
+func (b *Board) Validate() error {
+	turn, err := b.InferTurn()
+	if err != nil {
+		return err
+	}
+	won := make(map[Mark]bool)
+	for _, line := range b.lines {
+		if m := b.lineOwner(line); m != Empty {
+			won[m] = true
+		}
+	}
+	var winners []Mark
+	for _, m := range b.marks {
+		if won[m] {
+			winners = append(winners, m)
+		}
+	}
+	switch {
+	case len(winners) > 1:
+		return fmt.Errorf("unreachable position: both %c and %c have a line", winners[0], winners[1])
+	case len(winners) == 1 && turn != b.switchMark(winners[0]):
+		return fmt.Errorf("unreachable position: %c moved after %c won", b.switchMark(winners[0]), winners[0])
+	}
+	return nil
+}
+
+// IsDrawInevitable reports whether the game can only end in a draw, even with
+// cells left to play: nobody has won and every win line already holds marks
+// of both players.
+func IsDrawInevitable(b *Board) bool {
+	if _, ok := b.Winner(); ok {
+		return false
+	}
+	for _, line := range b.lines {
+		held := Empty
+		dead := false
+		for _, idx := range line {
+			c := b.cells[idx]
+			if c == Empty {
+				continue
+			}
+			if held != Empty && c != held {
+				dead = true
+				break
 			}
+			held = c
 		}
-		if r < 2 {
-			sb.WriteString("\n---------\n")
+		if !dead {
+			return false
 		}
 	}
-	return sb.String()
+	return true
 }
 
-// Player interface: returns index 0..8 for move
-type Player interface {
-	Move(b *Board, mark Mark) (int, error)
-	Name() string
+// Count returns how many cells hold m; Count(Empty) is the number of free
+// cells.
+func (b *Board) Count(m Mark) int {
+	n := 0
+	for _, c := range b.cells {
+		if c == m {
+			n++
+		}
+	}
+	return n
 }
 
-// Human CLI player
-type Human struct {
-	reader *bufio.Reader
-	name   string
+// OpenLines returns how many win lines m can still complete: those holding
+// no opponent marks.
+func (b *Board) OpenLines(m Mark) int {
+	n := 0
+	for _, line := range b.lines {
+		open := true
+		for _, idx := range line {
+			if c := b.cells[idx]; c != m && c != Empty {
+				open = false
+				break
+			}
+		}
+		if open {
+			n++
+		}
+	}
+	return n
 }
 
-func NewHuman(name string) *Human {
-	return &Human{reader: bufio.NewReader(os.Stdin), name: name}
+// Threats returns the free cells, in index order, where m would complete a
+// line on its next move.
+func (b *Board) Threats(m Mark) []int {
+	var cells []int
+	for idx := range b.cells {
+		if b.CompletesLine(idx, m) {
+			cells = append(cells, idx)
+		}
+	}
+	return cells
 }
 
-func (h *Human) Name() string { return h.name }
+// CompletesLine reports whether placing m on the free cell idx would fill a
+// win line with m. It is false if idx is off the board or occupied.
+func (b *Board) CompletesLine(idx int, m Mark) bool {
+	if b.CanMove(idx) != nil {
+		return false
+	}
+	for _, line := range b.lines {
+		if !slices.Contains(line, idx) {
+			continue
+		}
+		full := true
+		for _, i := range line {
+			if i != idx && b.cells[i] != m {
+				full = false
+				break
+			}
+		}
+		if full {
+			return true
+		}
+	}
+	return false
+}
 
-func (h *Human) Move(b *Board, mark Mark) (int, error) {
-	fmt.Printf("%s (%c), enter move (0-8): ", h.name, mark)
-	line, err := h.reader.ReadString('\n')
-	if err != nil {
-		return -1, err
+// InferTurn returns the side to move, judging by the counts of marks: the
+// first mover (X by default) when everyone has moved equally often, otherwise
+// the first player in turn order to be one move behind the players before
+// it. Any other counts are an error.
+func (b *Board) InferTurn() (Mark, error) {
+	counts := make([]int, len(b.marks))
+	for i, m := range b.marks {
+		counts[i] = b.Count(m)
 	}
-	line = strings.TrimSpace(line)
-	i, err := strconv.Atoi(line)
-	if err != nil {
-		return -1, errors.New("invalid number")
+	next := 0
+	for next < len(counts) && counts[next] == counts[0] {
+		next++
 	}
-	if i < 0 || i > 8 {
-		return -1, errors.New("index out of range")
+	valid := true
+	for _, n := range counts[next:] {
+		if n != counts[0]-1 {
+			valid = false
+		}
 	}
-	if b.cells[i] != Empty {
-		return -1, errors.New("cell occupied")
+	if valid {
+		return b.marks[next%len(b.marks)], nil
 	}
-	return i, nil
+	parts := make([]string, len(counts))
+	for i, n := range counts {
+		parts[i] = fmt.Sprintf("%d %c", n, b.marks[i])
+	}
+	last := len(parts) - 1
+	return Empty, fmt.Errorf("unreachable position: %s and %s", strings.Join(parts[:last], ", "), parts[last])
 }
 
-// Random player (for testing)
-type RandomPlayer struct{ name string }
-
-func NewRandom(name string) *RandomPlayer { return &RandomPlayer{name: name} }
-func (r *RandomPlayer) Name() string      { return r.name }
-func (r *RandomPlayer) Move(b *Board, mark Mark) (int, error) {
-	moves := b.AvailableMoves()
-	if len(moves) == 0 {
-		return -1, errors.New("no moves")
+// switchMark returns the mark of the player who moves after m, or the first
+// mover if m is not a player's mark.
+func (b *Board) switchMark(m Mark) Mark {
+	for i, pm := range b.marks {
+		if pm == m {
+			return b.marks[(i+1)%len(b.marks)]
+		}
 	}
-	return moves[rand.Intn(len(moves))], nil
+	return b.marks[0]
 }
 
-// Minimax AI player
-type MinimaxAI struct {
-	name string
-	me   Mark
+// isMark reports whether m is one of the players' marks.
+func (b *Board) isMark(m Mark) bool {
+	return slices.Contains(b.marks, m)
 }
 
-func NewMinimax(name string) *MinimaxAI { return &MinimaxAI{name: name} }
+// lineOwner returns the mark filling every cell of line, or Empty if the
+// line is not completely held by one mark.
+func (b *Board) lineOwner(line []int) Mark {
+	first := b.cells[line[0]]
+	for _, idx := range line[1:] {
+		if b.cells[idx] != first {
+			return Empty
+		}
+	}
+	return first
+}
 
-func (ai *MinimaxAI) Name() string { return ai.name }
+// hash returns a key identifying the position: the cells in order followed
+// by the side to move.
+func (b *Board) hash(toMove Mark) string {
+	var sb strings.Builder
+	sb.Grow(len(b.cells) + 1)
+	for _, c := range b.cells {
+		sb.WriteRune(rune(c))
+	}
+	sb.WriteRune(rune(toMove))
+	return sb.String()
+}
 
-// evaluate returns a score for a terminal board
-// +1 if AI wins, -1 if opponent wins, 0 if draw, NaN if not terminal
-func (ai *MinimaxAI) evaluate(b *Board) float64 {
-	if w, ok := b.Winner(); ok {
-		if w == ai.me {
-			return 1
+// Equal reports whether other has the same dimensions, marks and cell
+// contents. Move history is not compared.
+func (b *Board) Equal(other *Board) bool {
+	if b.rows != other.rows || b.cols != other.cols || b.winLen != other.winLen || !slices.Equal(b.marks, other.marks) {
+		return false
+	}
+	for i, c := range b.cells {
+		if other.cells[i] != c {
+			return false
 		}
-		return -1
 	}
-	if b.IsFull() {
-		return 0
+	return true
+}
+
+// Hash returns a 64-bit FNV-1a hash of the dimensions and every cell. Equal
+// boards hash alike, and the value is the same from run to run.
+func (b *Board) Hash() uint64 {
+	h := fnv.New64a()
+	var buf [8]byte
+	binary.LittleEndian.PutUint32(buf[:4], uint32(b.rows))
+	binary.LittleEndian.PutUint32(buf[4:], uint32(b.winLen))
+	h.Write(buf[:])
+	if b.cols != b.rows {
+		// Only written for rectangular boards, so square boards keep the
+		// hashes stored in saved opening books.
+		binary.LittleEndian.PutUint32(buf[:4], uint32(b.cols))
+		h.Write(buf[:4])
 	}
-	return math.NaN()
+	for _, c := range b.cells {
+		binary.LittleEndian.PutUint32(buf[:4], uint32(c))
+		h.Write(buf[:4])
+	}
+	return h.Sum64()
 }
 
-// Move picks best index using minimax
-func (ai *MinimaxAI) Move(b *Board, mark Mark) (int, error) {
-	ai.me = mark
-	bestScore := math.Inf(-1)
-	bestMove := -1
-	for _, mv := range b.AvailableMoves() {
-		nb := b.Clone()
-		_ = nb.MakeMove(mv, mark)
-		score := ai.minimax(nb, switchMark(mark), false)
-		if score > bestScore {
-			bestScore = score
-			bestMove = mv
-		}
+// Rotate90 returns a copy of the board rotated a quarter turn clockwise; the
+// original is left untouched. Move history is carried over with its indices
+// rotated too. It panics if the board is not square.
+func (b *Board) Rotate90() *Board {
+	if b.rows != b.cols {
+		panic(fmt.Sprintf("cannot rotate a %dx%d board", b.rows, b.cols))
+	}
+	n := b.rows - 1
+	return b.transform(func(r, c int) (int, int) { return n - c, r })
+}
+
+// Mirror returns a copy of the board reflected left to right; the original is
+// left untouched. Move history is carried over with its indices mirrored too.
+func (b *Board) Mirror() *Board {
+	n := b.cols - 1
+	return b.transform(func(r, c int) (int, int) { return r, n - c })
+}
+
+// Transpose returns a copy of the board reflected in its main diagonal, so
+// the cell at (r, c) moves to (c, r) and a rows x cols board becomes cols x
+// rows. The original is left untouched. Move history is carried over with its
+// indices transposed too.
+func (b *Board) Transpose() *Board {
+	nb := NewBoardMN(b.cols, b.rows, b.winLen)
+	nb.marks = b.marks
+	nb.maxHistory = b.maxHistory
+	dst := func(idx int) int {
+		r, c := b.RowCol(idx)
+		return nb.Index(c, r)
+	}
+	for i, m := range b.cells {
+		nb.cells[dst(i)] = m
 	}
-	if bestMove == -1 {
-		return -1, errors.New("no moves available")
+	for _, mv := range b.history {
+		nb.history = append(nb.history, Move{Index: dst(mv.Index), Mark: mv.Mark})
 	}
-	return bestMove, nil
+	for _, mv := range b.redo {
+		nb.redo = append(nb.redo, Move{Index: dst(mv.Index), Mark: mv.Mark})
+	}
+	return nb
+}
+
+// flip returns a copy of the board reflected top to bottom.
+func (b *Board) flip() *Board {
+	n := b.rows - 1
+	return b.transform(func(r, c int) (int, int) { return n - r, c })
 }
 
-// minimax with evaluation function
-func (ai *MinimaxAI) minimax(b *Board, current Mark, maximizing bool) float64 {
-	score := ai.evaluate(b)
-	if !math.IsNaN(score) {
-		return score
+// transform returns a copy of b whose cell (r, c) holds b's cell src(r, c).
+// src must be a permutation of the board's coordinates.
+func (b *Board) transform(src func(r, c int) (int, int)) *Board {
+	nb := b.Clone()
+	dst := make([]int, len(b.cells)) // old index -> new index
+	for r := 0; r < b.rows; r++ {
+		for c := 0; c < b.cols; c++ {
+			sr, sc := src(r, c)
+			to, from := b.Index(r, c), b.Index(sr, sc)
+			nb.cells[to] = b.cells[from]
+			dst[from] = to
+		}
+	}
+	for i := range nb.history {
+		nb.history[i].Index = dst[nb.history[i].Index]
 	}
+	for i := range nb.redo {
+		nb.redo[i].Index = dst[nb.redo[i].Index]
+	}
+	return nb
+}
 
-	if maximizing {
-		best := math.Inf(-1)
-		for _, mv := range b.AvailableMoves() {
-			nb := b.Clone()
-			_ = nb.MakeMove(mv, current)
-			score := ai.minimax(nb, switchMark(current), false)
-			if score > best {
-				best = score
-			}
+// CanonicalForm returns the lexicographically smallest of the board's eight
+// rotations and reflections, comparing cells in index order; a rectangular
+// board has only four, its reflections and half turn. Boards that are
+// symmetric to each other share a canonical form. The result has no move
+// history.
+func (b *Board) CanonicalForm() *Board {
+	var cands []*Board
+	if b.rows == b.cols {
+		cur := b
+		for i := 0; i < 4; i++ {
+			cands = append(cands, cur, cur.Mirror())
+			cur = cur.Rotate90()
 		}
-		return best
 	} else {
-		best := math.Inf(1)
-		for _, mv := range b.AvailableMoves() {
-			nb := b.Clone()
-			_ = nb.MakeMove(mv, current)
-			score := ai.minimax(nb, switchMark(current), true)
-			if score < best {
-				best = score
-			}
+		flipped := b.flip()
+		cands = []*Board{b, b.Mirror(), flipped, flipped.Mirror()}
+	}
+	best := b
+	for _, cand := range cands {
+		if lessCells(cand.cells, best.cells) {
+			best = cand
 		}
-		return best
 	}
+	best = best.Clone()
+	best.history, best.redo = nil, nil
+	return best
 }
 
-func switchMark(m Mark) Mark {
-	if m == X {
-		return O
+func lessCells(a, b []Mark) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return a[i] < b[i]
+		}
 	}
-	return X
+	return false
 }
 
-// Game orchestrator
-type Game struct {
-	board   *Board
-	pX, pO  Player
-	current Mark
+func (b *Board) String() string {
+	var sb strings.Builder
+	sep := "\n" + strings.Repeat("-", 4*b.cols-3) + "\n"
+	b.Each(func(idx, row, col int, m Mark) {
+		switch {
+		case col > 0:
+			sb.WriteString(" | ")
+		case row > 0:
+			sb.WriteString(sep)
+		}
+		sb.WriteRune(rune(m))
+	})
+	return sb.String()
 }
 
-func NewGame(px, po Player) *Game {
-	return &Game{
-		board:   NewBoard(),
-		pX:      px,
-		pO:      po,
-		current: X,
+// MarshalJSON encodes the cells row by row as one-character strings, e.g.
+// ["X",".","O",...].
+func (b *Board) MarshalJSON() ([]byte, error) {
+	cells := make([]string, len(b.cells))
+	for i, c := range b.cells {
+		cells[i] = string(c)
 	}
+	return json.Marshal(cells)
 }
 
-func (g *Game) Play() (Mark, error) {
-	for {
-		fmt.Println("\nBoard:")
-		fmt.Println(g.board.String())
-		if w, ok := g.board.Winner(); ok {
-			fmt.Printf("Winner: %c\n", w)
-			return w, nil
+// UnmarshalJSON decodes the format produced by MarshalJSON. The cell count must
+// match the board's dimensions; a zero Board is treated as 3x3. Any move
+// history is discarded.
+func (b *Board) UnmarshalJSON(data []byte) error {
+	var cells []string
+	if err := json.Unmarshal(data, &cells); err != nil {
+		return err
+	}
+	if b.cols == 0 {
+		*b = *NewBoard()
+	}
+	if len(cells) != len(b.cells) {
+		return fmt.Errorf("board: got %d cells, want %d", len(cells), len(b.cells))
+	}
+	parsed := make([]Mark, len(cells))
+	for i, c := range cells {
+		m := Mark(0)
+		if len([]rune(c)) == 1 {
+			m = Mark([]rune(c)[0])
 		}
-		if g.board.IsFull() {
-			fmt.Println("Draw")
-			return Empty, nil
+		if m != Empty && !b.isMark(m) {
+			return fmt.Errorf("board: invalid mark %q at cell %d", c, i)
 		}
+		parsed[i] = m
+	}
+	b.cells = parsed
+	b.history, b.redo = nil, nil
+	return nil
+}
 
-		var p Player
-		if g.current == X {
-			p = g.pX
-		} else {
-			p = g.pO
+// Player interface: returns index 0..rows*cols-1 for move. Implementations
+// should give up and return an error once ctx is done.
+type Player interface {
+	Move(ctx context.Context, b *Board, mark Mark) (int, error)
+	Name() string
+}
+
+// lineReader reads input lines without blocking past a context deadline. A
+// read abandoned by a cancelled call keeps running in the background and its
+// line is handed to the next call, so no input is lost.
+type lineReader struct {
+	r       *bufio.Reader
+	pending chan lineResult
+}
+
+type lineResult struct {
+	line string
+	err  error
+}
+
+func newLineReader(r io.Reader) *lineReader {
+	return &lineReader{r: bufio.NewReader(r)}
+}
+
+// ErrInputClosed is returned by players reading input lines, such as Human,
+// once their input has reached EOF. Play ends the game rather than asking
+// again.
+var ErrInputClosed = errors.New("input closed")
+
+// ReadLine returns the next line, or ErrInputClosed at EOF. A final line
+// without a newline is still returned.
+func (lr *lineReader) ReadLine(ctx context.Context) (string, error) {
+	if lr.pending == nil {
+		ch := make(chan lineResult, 1)
+		go func() {
+			line, err := lr.r.ReadString('\n')
+			ch <- lineResult{line, err}
+		}()
+		lr.pending = ch
+	}
+	select {
+	case res := <-lr.pending:
+		lr.pending = nil
+		if res.err == io.EOF {
+			if res.line == "" {
+				return "", ErrInputClosed
+			}
+			return res.line, nil
 		}
-		move, err := p.Move(g.board, g.current)
+		return res.line, res.err
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// ErrUndoRequested is returned by Human.Move when the player types "undo" (or
+// "u"). The game then takes back the player's last move and the reply to it.
+var ErrUndoRequested = errors.New("undo requested")
+
+// ErrForfeit is returned by Human.Move when the player types "quit" (or "q").
+// The game then ends with the opponent as the winner.
+var ErrForfeit = errors.New("player forfeits")
+
+// ErrResign is returned by a MinimaxAI with ResignWhenLost set when every
+// move loses. Like ErrForfeit it ends the game with the opponent as the
+// winner.
+var ErrResign = errors.New("player resigns")
+
+// Human CLI player
+type Human struct {
+	reader *lineReader
+	out    io.Writer
+	name   string
+
+	// Hinter suggests a move when the human types "hint" or "?". If nil a
+	// perfect-play MinimaxAI is used on 3x3 boards and an
+	// IterativeDeepeningAI with aiMoveBudget per hint on larger ones.
+	Hinter Player
+
+	// Messages holds the prompts shown; EnglishMessages if nil.
+	Messages *Messages
+}
+
+// NewHuman returns a player prompted on stdout and typing on stdin.
+func NewHuman(name string) *Human {
+	return NewHumanIO(name, os.Stdin, os.Stdout)
+}
+
+// NewHumanIO returns a player that reads moves from in and is prompted on out.
+func NewHumanIO(name string, in io.Reader, out io.Writer) *Human {
+	return &Human{reader: newLineReader(in), out: out, name: name}
+}
+
+func (h *Human) Name() string { return h.name }
+
+func (h *Human) Move(ctx context.Context, b *Board, mark Mark) (int, error) {
+	for {
+		fmt.Fprintf(h.out, messagesOr(h.Messages).Prompt, h.name, mark, len(b.cells)-1)
+		line, err := h.reader.ReadLine(ctx)
 		if err != nil {
-			fmt.Printf("Player move error: %v\n", err)
-			continue
+			return -1, err
 		}
-		if err := g.board.MakeMove(move, g.current); err != nil {
-			fmt.Printf("Invalid move: %v\n", err)
+		switch strings.ToLower(strings.TrimSpace(line)) {
+		case "undo", "u":
+			return -1, ErrUndoRequested
+		case "quit", "q":
+			return -1, ErrForfeit
+		case "hint", "?":
+			if err := h.hint(ctx, b, mark); err != nil {
+				return -1, err
+			}
 			continue
 		}
-		g.current = switchMark(g.current)
+		return parseMove(line, b)
 	}
 }
 
-func main() {
-	rand.Seed(time.Now().UnixNano())
-	reader := bufio.NewReader(os.Stdin)
-
-	fmt.Println("Tic-Tac-Toe - CLI demonstration")
-	// Example: Human vs Minimax
-	for {
-		h := NewHuman("You")
-		ai := NewMinimax("AI")
-		game := NewGame(h, ai) // Human is X, AI is O
+// hint prints the move Hinter would play for mark on b.
+func (h *Human) hint(ctx context.Context, b *Board, mark Mark) error {
+	hinter := h.Hinter
+	switch {
+	case hinter != nil:
+	case len(b.cells) > 9:
+		hinter = NewIterativeDeepening("hint", aiMoveBudget)
+	default:
+		hinter = NewMinimax("hint")
+	}
+	idx, err := hinter.Move(ctx, b.Clone(), mark)
+	if err != nil {
+		return fmt.Errorf("hint: %w", err)
+	}
+	row, col := b.RowCol(idx)
+	fmt.Fprintf(h.out, messagesOr(h.Messages).Hint, idx, row, col)
+	return nil
+}
 
-		winner, err := game.Play()
+// parseMove converts a line of player input into a free cell index on b. The
+// input is either a flat index or a zero-based "row,col" / "row col" pair.
+func parseMove(line string, b *Board) (int, error) {
+	fields := strings.FieldsFunc(line, func(r rune) bool {
+		return r == ',' || unicode.IsSpace(r)
+	})
+	var i int
+	switch len(fields) {
+	case 1:
+		n, err := strconv.Atoi(fields[0])
 		if err != nil {
-			fmt.Printf("Game ended with error: %v\n", err)
-			return
+			return -1, fmt.Errorf("%w: %q", ErrInvalidNumber, fields[0])
 		}
-		if winner == Empty {
-			fmt.Println("Game ended in a draw!")
-		} else {
-			fmt.Printf("Game over! Winner: %c\n", winner)
+		i = n
+	case 2:
+		row, err1 := strconv.Atoi(fields[0])
+		col, err2 := strconv.Atoi(fields[1])
+		if err1 != nil || err2 != nil {
+			return -1, fmt.Errorf("%w: %q", ErrInvalidNumber, strings.TrimSpace(line))
 		}
+		if row < 0 || row >= b.rows {
+			return -1, fmt.Errorf("%w: row %d not in 0-%d", ErrOutOfBounds, row, b.rows-1)
+		}
+		if col < 0 || col >= b.cols {
+			return -1, fmt.Errorf("%w: column %d not in 0-%d", ErrOutOfBounds, col, b.cols-1)
+		}
+		i = b.Index(row, col)
+	default:
+		return -1, fmt.Errorf("%w: %q", ErrInvalidNumber, strings.TrimSpace(line))
+	}
+	if err := b.CanMove(i); err != nil {
+		return -1, err
+	}
+	return i, nil
+}
+
+// Random player (for testing)
+type RandomPlayer struct {
+	name string
+	seed int64
+	rng  *rand.Rand
+}
+
+// NewRandom returns a RandomPlayer seeded from the current time.
+func NewRandom(name string) *RandomPlayer {
+	return NewRandomSeeded(name, time.Now().UnixNano())
+}
+
+// NewRandomSeeded returns a RandomPlayer whose choices are fully determined
+// by seed, so games against it can be reproduced.
+func NewRandomSeeded(name string, seed int64) *RandomPlayer {
+	return &RandomPlayer{name: name, seed: seed, rng: rand.New(rand.NewSource(seed))}
+}
+
+// Seed returns the seed the player was created with, also when NewRandom
+// picked it from the clock, so a game can be replayed with NewRandomSeeded.
+func (r *RandomPlayer) Seed() int64 { return r.seed }
+
+func (r *RandomPlayer) Name() string { return r.name }
+func (r *RandomPlayer) Move(ctx context.Context, b *Board, mark Mark) (int, error) {
+	moves := b.AvailableMoves()
+	if len(moves) == 0 {
+		return -1, ErrNoMoves
+	}
+	return moves[r.rng.Intn(len(moves))], nil
+}
+
+// WeightedRandomPlayer picks a free cell at random with probability
+// proportional to its weight.
+type WeightedRandomPlayer struct {
+	name string
+	rng  *rand.Rand
+
+	// Weights holds a weight per cell index; cells beyond its end weigh 0,
+	// and if no free cell has a positive weight all are equally likely. If
+	// nil, each cell is weighted by the number of win lines through it, which
+	// on 3x3 favors the center, then the corners.
+	Weights []float64
+}
+
+// NewWeightedRandom returns a WeightedRandomPlayer drawing from rng with the
+// given weights (see Weights). If rng is nil one seeded from the clock is
+// used.
+func NewWeightedRandom(name string, weights []float64, rng *rand.Rand) *WeightedRandomPlayer {
+	if rng == nil {
+		rng = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+	return &WeightedRandomPlayer{name: name, rng: rng, Weights: weights}
+}
+
+func (w *WeightedRandomPlayer) Name() string { return w.name }
+
+func (w *WeightedRandomPlayer) Move(ctx context.Context, b *Board, mark Mark) (int, error) {
+	moves := b.AvailableMoves()
+	if len(moves) == 0 {
+		return -1, ErrNoMoves
+	}
+	weights := w.Weights
+	if weights == nil {
+		weights = make([]float64, len(b.cells))
+		for _, line := range b.lines {
+			for _, idx := range line {
+				weights[idx]++
+			}
+		}
+	}
+	cum := make([]float64, len(moves)) // running total of the weights
+	total := 0.0
+	for i, mv := range moves {
+		if mv < len(weights) && weights[mv] > 0 {
+			total += weights[mv]
+		}
+		cum[i] = total
+	}
+	if total == 0 {
+		return moves[w.rng.Intn(len(moves))], nil
+	}
+	pick := w.rng.Float64() * total
+	for i, c := range cum {
+		if pick < c {
+			return moves[i], nil
+		}
+	}
+	return moves[len(moves)-1], nil // rounding
+}
+
+// Minimax AI player. It plays two-player games only.
+type MinimaxAI struct {
+	name string
+	me   Mark
+
+	// MaxDepth limits how many plies are searched below each candidate move
+	// before falling back to a heuristic estimate. Negative means unlimited.
+	MaxDepth int
+
+	// Verbose makes Move explain its choice by writing the score of every
+	// candidate move to Out (os.Stdout if nil).
+	Verbose bool
+	Out     io.Writer
+
+	// Evaluator scores positions during the search (WinLossEvaluator if
+	// nil). Below the depth limit only its terminal scores are used; at the
+	// limit its score for a non-terminal position replaces the built-in line
+	// heuristic, which is used only with the default evaluator.
+	Evaluator Evaluator
+
+	// Book, if set, is consulted before searching: a booked move for the
+	// position is played straight away.
+	Book OpeningBook
+
+	// Handicap is the probability, from 0 to 1, that Move deliberately plays
+	// the second-best move, even passing up a win. Rand supplies the dice,
+	// here and for TieBreakRandom; if nil one seeded from the clock is
+	// created on first use.
+	Handicap float64
+	Rand     *rand.Rand
+
+	// TieBreak chooses among equally good moves; the zero value prefers the
+	// center.
+	TieBreak TieBreak
+
+	// NoPruning turns off alpha-beta cutoffs so every move is searched, as
+	// in plain minimax. The chosen moves are the same, only slower to find;
+	// it exists to measure what pruning saves.
+	NoPruning bool
+
+	// ResignWhenLost makes Move return ErrResign instead of a move when the
+	// search finds that even the best move scores -1, a certain loss.
+	ResignWhenLost bool
+
+	// Misere makes the AI play the misère variant, where completing a line
+	// loses: it scores boards with MisereEvaluator unless Evaluator is set,
+	// and neither takes nor blocks an immediate line nor consults Book.
+	Misere bool
+
+	// LastSearchNodes is the number of positions the most recent Move call
+	// searched, 0 if it played without searching.
+	LastSearchNodes int
+
+	// cache is a transposition table of positions already scored during the
+	// current Move call. It is keyed by Board.hash; entries stay valid for the
+	// whole call because the remaining depth is fixed by how many marks are on
+	// the board.
+	cache map[string]ttEntry
+}
+
+// NewMinimax returns a perfect-play AI that searches to the end of the game.
+func NewMinimax(name string) *MinimaxAI { return &MinimaxAI{name: name, MaxDepth: -1} }
+
+// NewMinimaxDepth returns an AI limited to depth plies of lookahead. Depth 0
+// only looks at the position right after its own move.
+func NewMinimaxDepth(name string, depth int) *MinimaxAI {
+	return &MinimaxAI{name: name, MaxDepth: depth}
+}
+
+func (ai *MinimaxAI) Name() string { return ai.name }
+
+// Evaluator scores a board from me's point of view for MinimaxAI. terminal
+// reports whether the search should stop at b; a win should score higher than
+// any non-terminal estimate.
+type Evaluator interface {
+	Evaluate(b *Board, me Mark) (score float64, terminal bool)
+}
+
+// WinLossEvaluator is the default Evaluator: +1 if me has won, -1 if the
+// opponent has, 0 for a draw. Unfinished boards are not terminal and score 0.
+type WinLossEvaluator struct{}
+
+func (WinLossEvaluator) Evaluate(b *Board, me Mark) (float64, bool) {
+	if w, ok := b.Winner(); ok {
+		if w == me {
+			return 1, true
+		}
+		return -1, true
+	}
+	return 0, b.IsFull()
+}
+
+// MisereEvaluator scores the misère variant from me's point of view: -1 if me
+// has completed a line, +1 if the opponent has, 0 for a draw.
+type MisereEvaluator struct{}
+
+func (MisereEvaluator) Evaluate(b *Board, me Mark) (float64, bool) {
+	score, terminal := WinLossEvaluator{}.Evaluate(b, me)
+	return -score, terminal
+}
+
+// evaluate scores b for the AI with its Evaluator.
+func (ai *MinimaxAI) evaluate(b *Board) (float64, bool) {
+	switch {
+	case ai.Evaluator != nil:
+		return ai.Evaluator.Evaluate(b, ai.me)
+	case ai.Misere:
+		return MisereEvaluator{}.Evaluate(b, ai.me)
+	}
+	return WinLossEvaluator{}.Evaluate(b, ai.me)
+}
+
+// Weights used by heuristicScore.
+const (
+	threatWeight = 10 // line one mark short of a win, no opponent marks
+	centerWeight = 3  // holding the center cell (odd-sized boards only)
+)
+
+// heuristicScore estimates a non-terminal board from the AI's point of view.
+// Every line still open to one side scores lineWeight for that side, at most
+// threatWeight, and holding the center adds centerWeight. The AI's total minus
+// the opponent's is divided by more than the largest total either side can
+// reach, so it stays inside (-1, 1) and never outweighs a real win or loss.
+func (ai *MinimaxAI) heuristicScore(b *Board) float64 {
+	opp := b.switchMark(ai.me)
+	score, bound := 0.0, 0.0
+	for _, line := range b.lines {
+		nMe, nOpp := 0, 0
+		for _, idx := range line {
+			switch b.At(idx) {
+			case ai.me:
+				nMe++
+			case opp:
+				nOpp++
+			}
+		}
+		switch {
+		case nOpp == 0 && nMe > 0:
+			score += lineWeight(nMe, len(line))
+		case nMe == 0 && nOpp > 0:
+			score -= lineWeight(nOpp, len(line))
+		}
+		bound += threatWeight
+	}
+	if b.rows%2 == 1 && b.cols%2 == 1 {
+		switch b.At(len(b.cells) / 2) {
+		case ai.me:
+			score += centerWeight
+		case opp:
+			score -= centerWeight
+		}
+		bound += centerWeight
+	}
+	return score / (bound + 1)
+}
+
+// lineWeight scores a line holding n marks of one side out of winLen cells:
+// threatWeight for a line one mark short of winning, a tenth of that for each
+// mark fewer. On a 3x3 board a single mark scores 1.
+func lineWeight(n, winLen int) float64 {
+	if n >= winLen-1 {
+		return threatWeight
+	}
+	return threatWeight * math.Pow(10, float64(n-winLen+1))
+}
+
+// Move picks best index using minimax. If ctx is done mid-search it returns
+// the best of the candidates scored so far, or ctx's error if there are none.
+// On a finished board it returns ErrGameOver, and with more than two players
+// ErrTwoPlayersOnly.
+func (ai *MinimaxAI) Move(ctx context.Context, b *Board, mark Mark) (int, error) {
+	ai.me = mark
+	ai.LastSearchNodes = 0
+	if _, won := b.Winner(); won || b.IsFull() {
+		return -1, ErrGameOver
+	}
+	if len(b.marks) != 2 {
+		return -1, ErrTwoPlayersOnly
+	}
+	if ai.Handicap > 0 && ai.roll() < ai.Handicap {
+		if mv, ok := ai.secondBest(ctx, b, mark); ok {
+			ai.explain("move %d: handicap, passing over the best move\n", mv)
+			return mv, nil
+		}
+	}
+	if mv, why, ok := ai.quickMove(b, mark); ok {
+		ai.explain("move %d: %s\n", mv, why)
+		return mv, nil
+	}
+	mv, score, err := ai.searchBest(ctx, b, mark)
+	if err == nil && ai.ResignWhenLost && score <= -1 {
+		ai.explain("resigns: every move loses\n")
+		return -1, ErrResign
+	}
+	if err == nil && ai.Verbose && createsFork(b, mv, mark) {
+		ai.explain("move %d: creates a fork\n", mv)
+	}
+	return mv, err
+}
+
+// quickMove returns the move Move plays without searching, and why: an
+// immediate win, or else a block of the opponent's, or else a booked move. In
+// misère none of them applies. ResignWhenLost needs the search to judge the
+// position, so with it set only the win is taken.
+func (ai *MinimaxAI) quickMove(b *Board, mark Mark) (int, string, bool) {
+	if ai.Misere {
+		return -1, "", false
+	}
+	if mv, ok := findWinningMove(b, mark); ok {
+		return mv, "wins immediately", true
+	}
+	if ai.ResignWhenLost {
+		return -1, "", false
+	}
+	if mv, ok := findWinningMove(b, b.switchMark(mark)); ok {
+		return mv, "blocks the opponent's win", true
+	}
+	if mv, ok := ai.Book.Lookup(b); ok {
+		return mv, "from the opening book", true
+	}
+	return -1, "", false
+}
+
+// searchBest scores every candidate move for mark and returns the best one
+// with its score, choosing among equally good moves by TieBreak. If ctx is
+// done mid-search only the candidates scored so far are considered.
+func (ai *MinimaxAI) searchBest(ctx context.Context, b *Board, mark Mark) (int, float64, error) {
+	ranked := ai.rankMoves(ctx, b, mark)
+	if len(ranked) == 0 {
+		if err := ctx.Err(); err != nil {
+			return -1, 0, err
+		}
+		return -1, 0, ErrNoMoves
+	}
+	best := ai.breakTie(b, ranked)
+	return best.move, best.score, nil
+}
+
+// scoredMove is a candidate move with its search score.
+type scoredMove struct {
+	move  int
+	score float64
+}
+
+// rankMoves scores the candidate moves for mark and returns them best first,
+// equal scores in index order. If ctx is done mid-search only the candidates
+// scored so far are returned.
+func (ai *MinimaxAI) rankMoves(ctx context.Context, b *Board, mark Mark) []scoredMove {
+	ai.me = mark
+	ai.cache = make(map[string]ttEntry)
+	defer func() { ai.cache = nil }()
+	var ranked []scoredMove
+	seen := make(map[string]float64) // scores of canonical positions
+	// The search plays and takes back moves on its own copy of b, with no
+	// history limit so that every move can be unmade.
+	nb := b.Clone()
+	nb.maxHistory = 0
+	for _, mv := range b.AvailableMoves() {
+		_ = nb.MakeMove(mv, mark)
+		// A move symmetric to an earlier candidate scores the same, so it
+		// is not searched again.
+		key := nb.CanonicalForm().hash(mark)
+		if score, ok := seen[key]; ok {
+			_ = nb.UnmakeMove(mv)
+			ai.explain("move %d: score %.2f (symmetric)\n", mv, score)
+			ranked = append(ranked, scoredMove{mv, score})
+			continue
+		}
+		// Candidates get a full window so every score is exact, as Verbose
+		// and callers comparing moves expect; pruning happens below the root.
+		score := -ai.negamax(ctx, nb, b.switchMark(mark), ai.MaxDepth, math.Inf(-1), math.Inf(1))
+		_ = nb.UnmakeMove(mv)
+		if ctx.Err() != nil {
+			break // score is incomplete
+		}
+		seen[key] = score
+		ai.explain("move %d: score %.2f\n", mv, score)
+		ranked = append(ranked, scoredMove{mv, score})
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].score > ranked[j].score
+	})
+	return ranked
+}
+
+// TieBreak is how MinimaxAI chooses among moves with the same best score.
+type TieBreak int
+
+const (
+	// TieBreakCenter plays the tied move nearest the center of the board,
+	// the lowest index among equally near ones. It is the default.
+	TieBreakCenter TieBreak = iota
+	// TieBreakFirst plays the lowest-indexed tied move.
+	TieBreakFirst
+	// TieBreakRandom plays a tied move chosen with Rand.
+	TieBreakRandom
+)
+
+// breakTie picks the move to play from ranked, which is sorted best first.
+func (ai *MinimaxAI) breakTie(b *Board, ranked []scoredMove) scoredMove {
+	n := 1
+	for n < len(ranked) && ranked[n].score == ranked[0].score {
+		n++
+	}
+	tied := ranked[:n]
+	switch ai.TieBreak {
+	case TieBreakFirst:
+		return tied[0] // ranked keeps index order among equal scores
+	case TieBreakRandom:
+		ai.roll() // make sure Rand is set
+		return tied[ai.Rand.Intn(len(tied))]
+	}
+	best := tied[0]
+	for _, sm := range tied[1:] {
+		if centerDistance(b, sm.move) < centerDistance(b, best.move) {
+			best = sm
+		}
+	}
+	return best
+}
+
+// centerDistance returns the squared distance of cell idx from the center of
+// the board, doubled so it stays an integer on even-sized boards.
+func centerDistance(b *Board, idx int) int {
+	r, c := b.RowCol(idx)
+	dr, dc := 2*r-(b.rows-1), 2*c-(b.cols-1)
+	return dr*dr + dc*dc
+}
+
+// secondBest returns the runner-up move for Handicap: the best candidate
+// scoring below the move Move would otherwise play, whether from quickMove or
+// the search, or failing that any other free cell.
+func (ai *MinimaxAI) secondBest(ctx context.Context, b *Board, mark Mark) (int, bool) {
+	ranked := ai.rankMoves(ctx, b, mark)
+	if len(ranked) == 0 {
+		return -1, false
+	}
+	top := ai.breakTie(b, ranked)
+	if mv, _, ok := ai.quickMove(b, mark); ok {
+		if i := slices.IndexFunc(ranked, func(sm scoredMove) bool { return sm.move == mv }); i >= 0 {
+			top = ranked[i]
+		}
+	}
+	for _, sm := range ranked {
+		if sm.score < top.score {
+			return sm.move, true
+		}
+	}
+	for _, sm := range ranked {
+		if sm.move != top.move {
+			return sm.move, true
+		}
+	}
+	return -1, false
+}
+
+// roll returns a random number in [0, 1) from Rand.
+func (ai *MinimaxAI) roll() float64 {
+	if ai.Rand == nil {
+		ai.Rand = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+	return ai.Rand.Float64()
+}
+
+// explain writes a Verbose message.
+func (ai *MinimaxAI) explain(format string, args ...any) {
+	if !ai.Verbose {
+		return
+	}
+	w := ai.Out
+	if w == nil {
+		w = os.Stdout
+	}
+	fmt.Fprintf(w, format, args...)
+}
+
+// BestLine returns the principal variation from b with mark to move: the
+// moves both sides play under the AI's search until the game ends, along with
+// the final score from mark's point of view (+1 win, -1 loss, 0 draw). Every
+// move comes from the search itself, bypassing the shortcuts, handicap and
+// resigning of Move.
+func (ai *MinimaxAI) BestLine(b *Board, mark Mark) ([]int, float64) {
+	nb := b.Clone()
+	var line []int
+	for turn := mark; ; turn = b.switchMark(turn) {
+		if _, over := nb.Winner(); over || nb.IsFull() {
+			break
+		}
+		mv, _, err := ai.searchBest(context.Background(), nb, turn)
+		if err != nil {
+			break
+		}
+		_ = nb.MakeMove(mv, turn)
+		line = append(line, mv)
+	}
+	ai.me = mark
+	score, _ := ai.evaluate(nb)
+	return line, score
+}
+
+// findWinningMove returns the lowest free cell where m would complete a line
+// straight away.
+func findWinningMove(b *Board, m Mark) (int, bool) {
+	if _, over := b.Winner(); over {
+		return -1, false
+	}
+	for _, mv := range b.AvailableMoves() {
+		if b.CompletesLine(mv, m) {
+			return mv, true
+		}
+	}
+	return -1, false
+}
+
+// ttEntry is a transposition table entry. With alpha-beta pruning a search
+// cut short by the window only yields a bound on the true score.
+type ttEntry struct {
+	score float64
+	bound ttBound
+}
+
+type ttBound int
+
+const (
+	exact ttBound = iota
+	lowerBound
+	upperBound
+)
+
+// negamax scores b from the point of view of current, the side to move: a
+// position's value for one side is minus its value for the other, so a single
+// maximizing search serves both. depth is the number of plies left to search,
+// negative for unlimited. Scores are exact inside the (alpha, beta) window;
+// outside it they only bound the true value, which is all the caller needs to
+// prune. Once ctx is done the returned score is meaningless and nothing more
+// is cached.
+func (ai *MinimaxAI) negamax(ctx context.Context, b *Board, current Mark, depth int, alpha, beta float64) float64 {
+	if ctx.Err() != nil {
+		return 0
+	}
+	key := b.hash(current)
+	if e, ok := ai.cache[key]; ok {
+		switch {
+		case e.bound == exact,
+			e.bound == lowerBound && e.score >= beta,
+			e.bound == upperBound && e.score <= alpha:
+			return e.score
+		}
+	}
+	score := ai.search(ctx, b, current, depth, alpha, beta)
+	if ctx.Err() == nil {
+		e := ttEntry{score: score, bound: exact}
+		if score <= alpha {
+			e.bound = upperBound
+		} else if score >= beta {
+			e.bound = lowerBound
+		}
+		ai.cache[key] = e
+	}
+	return score
+}
+
+// search scores b for negamax, recursing into the available moves until one
+// refutes the window. Each move is played on b and taken back again, so b is
+// unchanged on return.
+func (ai *MinimaxAI) search(ctx context.Context, b *Board, current Mark, depth int, alpha, beta float64) float64 {
+	ai.LastSearchNodes++
+	// evaluate and heuristicScore score for the AI; flip them for the opponent.
+	sign := 1.0
+	if current != ai.me {
+		sign = -1
+	}
+	score, terminal := ai.evaluate(b)
+	if terminal {
+		return sign * score
+	}
+	if depth == 0 {
+		switch {
+		case ai.Evaluator != nil:
+			return sign * score
+		case ai.Misere:
+			// Open lines the heuristic rewards are liabilities here.
+			return -sign * ai.heuristicScore(b)
+		}
+		return sign * ai.heuristicScore(b)
+	}
+
+	best := math.Inf(-1)
+	for _, mv := range b.orderedMoves() {
+		_ = b.MakeMove(mv, current)
+		score := -ai.negamax(ctx, b, b.switchMark(current), depth-1, -beta, -alpha)
+		_ = b.UnmakeMove(mv)
+		if score > best {
+			best = score
+		}
+		if best > alpha {
+			alpha = best
+		}
+		if alpha >= beta && !ai.NoPruning {
+			break // the opponent will avoid this position
+		}
+	}
+	return best
+}
+
+// Game orchestrator
+type Game struct {
+	board   *Board
+	players []Player // in turn order, matching board.marks
+	current Mark
+
+	// forfeited is set when the current player quits or resigns.
+	forfeited bool
+
+	// timings holds how long each Player.Move call took, in order.
+	timings []time.Duration
+
+	// onMove holds the callbacks registered with OnMove.
+	onMove []func(mark Mark, index int, board *Board)
+
+	// subs holds the channels handed out by Subscribe, until the game ends.
+	subs []chan *Board
+
+	// MoveTimeout, if positive, is the deadline given to each Player.Move
+	// call. A player that runs out of time is treated like one that errored.
+	MoveTimeout time.Duration
+
+	// Out receives Play's narration unless Log is set; os.Stdout if nil.
+	Out io.Writer
+
+	// Renderer draws the board Play shows before every turn. If nil the
+	// board is drawn plainly, with free cells numbered for a human to move.
+	Renderer Renderer
+
+	// ClearScreen makes Play clear the terminal before drawing each turn so
+	// the board stays in place. Leave it off when Out is not a terminal.
+	ClearScreen bool
+
+	// Misere plays the misère variant: the player who completes a line loses
+	// and the next player in turn wins. AI players need their own Misere set
+	// to play for it.
+	Misere bool
+
+	// Messages holds Play's narration; EnglishMessages if nil.
+	Messages *Messages
+
+	// Log, if set, receives a structured event for every move played, every
+	// failed or forfeited turn and the end of the game. Play then also sends
+	// its narration there, one Info record per message, instead of to Out.
+	Log *slog.Logger
+}
+
+func NewGame(px, po Player) *Game {
+	return NewGameWithMarks(px, po, X, O)
+}
+
+// NewGameRandomStart is like NewGame but a coin flip with rng decides which
+// of px and po plays X and moves first. If rng is nil one seeded from the
+// clock is used.
+func NewGameRandomStart(px, po Player, rng *rand.Rand) *Game {
+	if rng == nil {
+		rng = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+	if rng.Intn(2) == 1 {
+		px, po = po, px
+	}
+	return NewGame(px, po)
+}
+
+// NewGameN is like NewGame but played on a size x size board where winLen in
+// a row wins. It panics on invalid dimensions, as NewBoardN does.
+func NewGameN(px, po Player, size, winLen int) *Game {
+	g := NewGame(px, po)
+	g.board = NewBoardN(size, winLen)
+	return g
+}
+
+// NewGameWithMarks is like NewGame but px plays (and moves first) with markX
+// and po with markO instead of X and O. It panics if either mark is Empty or
+// they are equal.
+func NewGameWithMarks(px, po Player, markX, markO Mark) *Game {
+	if markX == Empty || markO == Empty || markX == markO {
+		panic(fmt.Sprintf("invalid marks %q and %q", markX, markO))
+	}
+	b := NewBoard()
+	b.marks = []Mark{markX, markO}
+	return &Game{
+		board:   b,
+		players: []Player{px, po},
+		current: markX,
+	}
+}
+
+// NewMultiGame returns a game for any number of players on a size x size
+// board where winLen in a row wins. players[i] plays marks[i], and they move
+// in that order. It panics unless there are at least two players, each with
+// its own mark other than Empty, or on invalid dimensions. The search-based
+// AIs cannot take a seat with more than two players; their moves fail with
+// ErrTwoPlayersOnly.
+func NewMultiGame(players []Player, marks []Mark, size, winLen int) *Game {
+	if len(players) < 2 || len(players) != len(marks) {
+		panic(fmt.Sprintf("invalid game: %d players with %d marks", len(players), len(marks)))
+	}
+	for i, m := range marks {
+		if m == Empty || slices.Contains(marks[:i], m) {
+			panic(fmt.Sprintf("invalid marks %q", marks))
+		}
+	}
+	b := NewBoardN(size, winLen)
+	b.marks = slices.Clone(marks)
+	return &Game{
+		board:   b,
+		players: slices.Clone(players),
+		current: marks[0],
+	}
+}
+
+// NewGameFromBoard starts a game from a position already on b, such as a
+// puzzle, with toMove to play. The board must pass Validate and toMove must
+// be the side whose turn it is there. The game plays on a copy of b, which
+// must be a two-player board.
+func NewGameFromBoard(b *Board, toMove Mark, px, po Player) (*Game, error) {
+	if len(b.marks) != 2 {
+		return nil, fmt.Errorf("board has %d players, want 2", len(b.marks))
+	}
+	if err := b.Validate(); err != nil {
+		return nil, err
+	}
+	want, _ := b.InferTurn() // checked by Validate
+	if toMove != want {
+		return nil, fmt.Errorf("%c is to move, not %c", want, toMove)
+	}
+	return &Game{board: b.Clone(), players: []Player{px, po}, current: toMove}, nil
+}
+
+// GameResult describes a finished game.
+type GameResult struct {
+	Winner   Mark   // Empty for a draw
+	Moves    []Move // every move played, in order
+	NumMoves int
+
+	// Snapshots holds an independent copy of the board after each move in
+	// Moves.
+	Snapshots []*Board
+}
+
+// Play runs the game to completion, narrating on Out, or on Log if set: it
+// shows the board before every turn and reprompts when a player errors or
+// picks an illegal cell. It gives up with an error wrapping ErrInputClosed if a
+// player's input runs out.
+func (g *Game) Play() (Mark, error) {
+	out := g.Out
+	if out == nil {
+		out = os.Stdout
+	}
+	say := func(s string) { fmt.Fprint(out, s) }
+	if g.Log != nil {
+		say = func(s string) { g.Log.Info(strings.TrimSpace(s)) }
+	}
+	msgs := messagesOr(g.Messages)
+	var failed error // shown after the redraw when the screen is cleared
+	res, err := g.run(context.Background(), func(b *Board) {
+		_, human := g.CurrentPlayer().(*Human)
+		_, over := g.IsOver()
+		humanToMove := human && !over
+		if g.ClearScreen && g.Log == nil {
+			fmt.Fprint(out, ansiClear)
+		}
+		var drawing string
+		switch {
+		case g.Renderer != nil:
+			drawing = g.Renderer.Render(b)
+		case humanToMove:
+			// Number the free cells for a human about to choose one.
+			drawing = b.StringWithHints()
+		default:
+			drawing = b.String()
+		}
+		say(msgs.Board + drawing + "\n")
+		if humanToMove && !g.Misere {
+			opp := b.switchMark(g.current)
+			if cells := b.Threats(opp); len(cells) > 0 {
+				say(fmt.Sprintf(msgs.Threat, opp, cells))
+			}
+		}
+		if failed != nil {
+			say(fmt.Sprintf(msgs.Invalid, failed))
+			failed = nil
+		}
+	}, func(err error) {
+		if g.ClearScreen {
+			failed = err
+			return
+		}
+		say(fmt.Sprintf(msgs.Invalid, err))
+	})
+	if err != nil {
+		return Empty, err
+	}
+	if g.forfeited {
+		say(fmt.Sprintf(msgs.Forfeit, g.current))
+	}
+	if res.Winner != Empty {
+		say(fmt.Sprintf(msgs.Win, res.Winner))
+	} else {
+		say(msgs.Draw)
+	}
+	return res.Winner, nil
+}
+
+// PlayHeadless runs the game to completion without printing anything. Unlike
+// Play, an erroring player or an illegal move ends the game with an error.
+func (g *Game) PlayHeadless() (GameResult, error) {
+	return g.PlayHeadlessContext(context.Background())
+}
+
+// PlayHeadlessContext is like PlayHeadless but gives up between moves, or
+// during one, once ctx is done, returning ctx's error and the moves so far.
+func (g *Game) PlayHeadlessContext(ctx context.Context) (GameResult, error) {
+	return g.run(ctx, nil, nil)
+}
+
+// run is the game loop shared by Play and PlayHeadless. show, if non-nil, is
+// called with the board before every turn. retry, if non-nil, is told about a
+// failed move and the player is asked again; otherwise, or if the player's
+// input is closed, the failure is returned. The loop stops with ctx's error
+// once ctx is done. However it stops, the subscribers' channels are closed.
+func (g *Game) run(ctx context.Context, show func(b *Board), retry func(err error)) (GameResult, error) {
+	defer g.closeSubs()
+	for {
+		if show != nil {
+			show(g.board)
+		}
+		if w, over := g.IsOver(); over {
+			g.end(w)
+			return g.result(w), nil
+		}
+		if err := ctx.Err(); err != nil {
+			return g.result(Empty), err
+		}
+		p := g.CurrentPlayer()
+		if err := g.takeTurn(ctx, p); err != nil {
+			if retry == nil || errors.Is(err, ErrInputClosed) {
+				return g.result(Empty), fmt.Errorf("player %s: %w", p.Name(), err)
+			}
+			retry(err)
+		}
+	}
+}
+
+// Step advances the game by one move of the current player, for callers that
+// drive the loop themselves (a GUI rendering between moves, say). It reports
+// whether the game is now over and who won, Empty for a draw. If the player
+// fails to produce a legal move the error is returned and the turn is not
+// passed. Once the game is over Step does nothing and reports done again.
+func (g *Game) Step() (done bool, winner Mark, err error) {
+	if w, over := g.IsOver(); over {
+		return true, w, nil
+	}
+	p := g.CurrentPlayer()
+	if err := g.takeTurn(context.Background(), p); err != nil {
+		return false, Empty, fmt.Errorf("player %s: %w", p.Name(), err)
+	}
+	winner, done = g.IsOver()
+	if done {
+		g.end(winner)
+	}
+	return done, winner, nil
+}
+
+// CurrentPlayer returns the player whose turn it is.
+func (g *Game) CurrentPlayer() Player {
+	return g.players[slices.Index(g.board.marks, g.current)]
+}
+
+// takeTurn asks p for a move and plays it, or handles its undo request or
+// forfeit.
+func (g *Game) takeTurn(ctx context.Context, p Player) error {
+	move, err := g.askMove(ctx, p)
+	if errors.Is(err, ErrForfeit) || errors.Is(err, ErrResign) {
+		g.forfeited = true
+		g.logger().Info("forfeit", "player", p.Name(), "mark", string(g.current))
+		return nil
+	}
+	if errors.Is(err, ErrUndoRequested) {
+		if err = g.undoTurn(); err == nil {
+			g.logger().Info("undo", "player", p.Name(), "mark", string(g.current))
+			return nil
+		}
+	}
+	if err != nil {
+		err = fmt.Errorf("move error: %w", err)
+	} else if err = g.applyMove(move); err != nil {
+		err = fmt.Errorf("invalid move: %w", err)
+	}
+	if err != nil {
+		g.logger().Warn("turn failed", "player", p.Name(), "mark", string(g.current), "err", err)
+	}
+	return err
+}
+
+// logger returns Log, or a logger that discards everything if it is nil.
+func (g *Game) logger() *slog.Logger {
+	if g.Log == nil {
+		return slog.New(slog.DiscardHandler)
+	}
+	return g.Log
+}
+
+// end logs the end of the game, won by winner or drawn if it is Empty, and
+// closes the subscribers' channels.
+func (g *Game) end(winner Mark) {
+	g.closeSubs()
+	if winner == Empty {
+		g.logger().Info("game over", "result", "draw", "moves", g.MoveCount())
+		return
+	}
+	g.logger().Info("game over", "result", "win", "winner", string(winner), "moves", g.MoveCount())
+}
+
+// closeSubs closes and forgets the channels handed out by Subscribe.
+func (g *Game) closeSubs() {
+	for _, ch := range g.subs {
+		close(ch)
+	}
+	g.subs = nil
+}
+
+// CurrentMark returns the mark of the side to move.
+func (g *Game) CurrentMark() Mark { return g.current }
+
+// MoveCount returns the number of moves played so far, less any undone.
+func (g *Game) MoveCount() int {
+	return len(g.board.cells) - g.board.LegalMoveCount()
+}
+
+// IsOver reports whether the game is over and who won, Empty for a draw. A
+// game ends as a draw as soon as one is inevitable, and is won by the
+// opponent of a player who forfeits. With Misere set, the line's owner loses
+// to the player after them.
+func (g *Game) IsOver() (Mark, bool) {
+	if g.forfeited {
+		return g.board.switchMark(g.current), true
+	}
+	if w, ok := g.board.Winner(); ok {
+		if g.Misere {
+			return g.board.switchMark(w), true
+		}
+		return w, true
+	}
+	if g.board.IsFull() || IsDrawInevitable(g.board) {
+		return Empty, true
+	}
+	return Empty, false
+}
+
+// Outcome is the state of a game as reported by Result.
+type Outcome int
+
+const (
+	OutcomeInProgress Outcome = iota
+	OutcomeXWins              // the first mover won
+	OutcomeOWins              // the second mover, or with more players any later one, won
+	OutcomeDraw
+)
+
+// Result reports how the game stands, by the same rules as IsOver. X and O
+// stand for the first and second movers, whatever their marks.
+func (g *Game) Result() Outcome {
+	w, over := g.IsOver()
+	switch {
+	case !over:
+		return OutcomeInProgress
+	case w == Empty:
+		return OutcomeDraw
+	case w == g.board.marks[0]:
+		return OutcomeXWins
+	}
+	return OutcomeOWins
+}
 
-		// Ask to play again
-		fmt.Print("Do you want to play again? (y/n): ")
-		answer, _ := reader.ReadString('\n')
-		answer = strings.TrimSpace(strings.ToLower(answer))
-
-		if answer != "y" {
-			fmt.Println("Thanks for playing! Goodbye 👋")
+// applyMove places the current mark at idx, passes the turn and tells the
+// OnMove callbacks.
+func (g *Game) applyMove(idx int) error {
+	mark := g.current
+	if err := g.board.MakeMove(idx, mark); err != nil {
+		return err
+	}
+	g.current = g.board.switchMark(mark)
+	g.logger().Info("move", "mark", string(mark), "index", idx)
+	for _, fn := range g.onMove {
+		fn(mark, idx, g.board)
+	}
+	for _, ch := range g.subs {
+		publish(ch, g.board.Clone())
+	}
+	return nil
+}
+
+// publish sends b on ch, first dropping the oldest board queued on ch if it
+// is full, so a slow subscriber never holds up the game.
+func publish(ch chan *Board, b *Board) {
+	for {
+		select {
+		case ch <- b:
+			return
+		default:
+		}
+		select {
+		case <-ch:
+		default:
+		}
+	}
+}
+
+// OnMove registers fn to be called after every move is applied, with the
+// mark played, the cell and the live board. Callbacks run in the order they
+// were registered and must not modify the board.
+func (g *Game) OnMove(fn func(mark Mark, index int, board *Board)) {
+	g.onMove = append(g.onMove, fn)
+}
+
+// Subscribe returns a channel that receives a copy of the board after every
+// move from now on. It is closed when Step sees the game end and whenever
+// Play or PlayHeadless returns, even with an error. Each call returns a new
+// channel. The channel buffers a board per cell; if a subscriber falls
+// further behind, which undos make possible, the oldest boards are dropped
+// rather than holding up the game. If the game is already over the channel
+// is closed straight away.
+func (g *Game) Subscribe() <-chan *Board {
+	ch := make(chan *Board, len(g.board.cells))
+	if _, over := g.IsOver(); over {
+		close(ch)
+		return ch
+	}
+	g.subs = append(g.subs, ch)
+	return ch
+}
+
+// undoTurn takes back one move per player, normally the requesting player's
+// own move and the opponents' replies, so the same player is to move again.
+// With fewer moves on the board just those are taken back.
+func (g *Game) undoTurn() error {
+	for i := 0; i < len(g.players); i++ {
+		if err := g.board.Undo(); err != nil {
+			if i == 0 {
+				return err
+			}
 			break
 		}
+		g.current = g.board.switchMark(g.current)
+	}
+	return nil
+}
+
+// askMove asks p for its next move under ctx, enforcing MoveTimeout.
+func (g *Game) askMove(ctx context.Context, p Player) (int, error) {
+	if g.MoveTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, g.MoveTimeout)
+		defer cancel()
+	}
+	start := time.Now()
+	defer func() { g.timings = append(g.timings, time.Since(start)) }()
+	return p.Move(ctx, g.board, g.current)
+}
+
+// MoveTimings returns how long every Player.Move call so far took, in call
+// order. Calls that failed or asked for an undo are included.
+func (g *Game) MoveTimings() []time.Duration {
+	return append([]time.Duration(nil), g.timings...)
+}
+
+func (g *Game) result(winner Mark) GameResult {
+	moves := g.MoveLog()
+	return GameResult{Winner: winner, Moves: moves, NumMoves: len(moves), Snapshots: g.snapshots(moves)}
+}
+
+// snapshots replays moves, the last ones played on the board, from the
+// position before them and returns a copy of the board after each.
+func (g *Game) snapshots(moves []Move) []*Board {
+	b := g.board.Clone()
+	for range moves {
+		b.Undo()
+	}
+	snaps := make([]*Board, len(moves))
+	for i, mv := range moves {
+		b.MakeMove(mv.Index, mv.Mark)
+		snaps[i] = b.Clone()
+	}
+	return snaps
+}
+
+// Board returns the game's live board.
+func (g *Game) Board() *Board { return g.board }
+
+// MoveLog returns the moves played so far, in order. If the board's history
+// is capped with SetMaxHistory only the most recent moves are returned.
+func (g *Game) MoveLog() []Move {
+	return append([]Move(nil), g.board.history...)
+}
+
+// seatPlayers returns the players in X, O order for a human who chose to
+// play as mark (X or O); the AI takes the other side. X always moves first.
+func seatPlayers(human, ai Player, mark Mark) (px, po Player) {
+	if mark == O {
+		return ai, human
+	}
+	return human, ai
+}
+
+func main() {
+	cfg, err := parseConfig(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if cfg.Mode == "analyze" {
+		if err := analyzeInput(os.Stdin, os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+	reader := bufio.NewReader(os.Stdin)
+	msgs := messagesOr(cfg.Messages)
+
+	fmt.Printf(msgs.Welcome, modes[cfg.Mode], cfg.Size, cfg.Size)
+	scores := NewScoreboard()
+	for {
+		px, po := cfg.players(reader)
+		game := NewGameN(px, po, cfg.Size, cfg.Size)
+		game.ClearScreen = cfg.Clear
+		game.Messages = cfg.Messages
+
+		winner, err := game.Play()
+		if errors.Is(err, ErrInputClosed) {
+			fmt.Print(msgs.InputClosed)
+			return
+		}
+		if err != nil {
+			fmt.Printf(msgs.GameError, err)
+			return
+		}
+		if winner == Empty {
+			fmt.Print(msgs.GameDrawn)
+		} else {
+			fmt.Printf(msgs.GameWon, winner)
+		}
+		if err := scores.Record(game, winner); err != nil {
+			fmt.Println(err)
+		}
+		fmt.Printf(msgs.Score, scores.Summary())
+
+		if !playAgain(reader, &cfg) {
+			fmt.Print(msgs.Goodbye)
+			break
+		}
+	}
+}
+
+// playAgain asks whether to play another game, possibly on a new board size,
+// which it stores in cfg. It asks again after an invalid size.
+func playAgain(reader *bufio.Reader, cfg *config) bool {
+	for {
+		fmt.Print(messagesOr(cfg.Messages).PlayAgain)
+		answer, _ := reader.ReadString('\n')
+		size, again, err := parsePlayAgain(answer, cfg.Size)
+		if err != nil {
+			fmt.Println(err)
+			continue
+		}
+		cfg.Size = size
+		return again
 	}
 }
@@ -0,0 +1,2564 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// midgameBoard returns the position after X@4, O@0, X@8, with O to move.
+func midgameBoard() *Board {
+	b := NewBoard()
+	_ = b.MakeMove(4, X)
+	_ = b.MakeMove(0, O)
+	_ = b.MakeMove(8, X)
+	return b
+}
+
+// benchmarkMove times p choosing a move on b. Move leaves b unchanged, so
+// one board serves every iteration.
+func benchmarkMove(bm *testing.B, p Player, b *Board, mark Mark) {
+	ctx := context.Background()
+	bm.ResetTimer()
+	for i := 0; i < bm.N; i++ {
+		if _, err := p.Move(ctx, b, mark); err != nil {
+			bm.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkMinimaxOpening(bm *testing.B) {
+	benchmarkMove(bm, NewMinimax("AI"), NewBoard(), X)
+}
+
+func BenchmarkMinimaxMidgame(bm *testing.B) {
+	benchmarkMove(bm, NewMinimax("AI"), midgameBoard(), O)
+}
+
+func BenchmarkRandomPlayer(bm *testing.B) {
+	benchmarkMove(bm, NewRandom("Random"), NewBoard(), X)
+}
+
+func TestNewBoardNDiagonalWin(t *testing.T) {
+	tests := []struct {
+		name  string
+		cells []int
+	}{
+		{"main diagonal", []int{0, 6, 12, 18, 24}},
+		{"anti-diagonal", []int{4, 8, 12, 16, 20}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewBoardN(5, 5)
+			for i, idx := range tt.cells {
+				if w, ok := b.Winner(); ok {
+					t.Fatalf("Winner() = %c after %d marks", w, i)
+				}
+				if err := b.MakeMove(idx, X); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if w, ok := b.Winner(); !ok || w != X {
+				t.Fatalf("Winner() = %c, %v; want X, true", w, ok)
+			}
+			if n := len(b.AvailableMoves()); n != 20 {
+				t.Errorf("%d free cells, want 20", n)
+			}
+		})
+	}
+}
+
+func TestUndoRedo(t *testing.T) {
+	b := NewBoard()
+	for _, mv := range []Move{{0, X}, {4, O}, {8, X}} {
+		if err := b.MakeMove(mv.Index, mv.Mark); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 0; i < 2; i++ {
+		if err := b.Undo(); err != nil {
+			t.Fatalf("Undo %d: %v", i+1, err)
+		}
+	}
+	if err := b.Redo(); err != nil {
+		t.Fatalf("Redo: %v", err)
+	}
+	want := map[int]Mark{0: X, 4: O, 8: Empty}
+	for idx, m := range want {
+		if got := b.At(idx); got != m {
+			t.Errorf("cell %d = %q, want %q", idx, got, m)
+		}
+	}
+
+	// A new move discards what is left to redo.
+	if err := b.MakeMove(2, X); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Redo(); err == nil {
+		t.Error("Redo after a new move succeeded")
+	}
+}
+
+func TestBoardJSONRoundTrip(t *testing.T) {
+	b := midgameBoard()
+	data, err := json.Marshal(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got Board
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if !got.Equal(b) {
+		t.Fatalf("round trip of %s gave\n%s\nwant\n%s", data, got.String(), b.String())
+	}
+}
+
+func TestBoardUnmarshalJSONErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"too few cells", `["X","O","."]`},
+		{"too many cells", `[".",".",".",".",".",".",".",".",".","."]`},
+		{"unknown mark", `["X","Z",".",".",".",".",".",".","."]`},
+		{"two-letter mark", `["XO",".",".",".",".",".",".",".","."]`},
+		{"not an array", `{"cells":9}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b Board
+			if err := json.Unmarshal([]byte(tt.data), &b); err == nil {
+				t.Errorf("Unmarshal(%s) succeeded", tt.data)
+			}
+		})
+	}
+}
+
+// beatable reports whether ai, playing aiMark, can be beaten from b with turn
+// to move, trying every reply for its opponent. b is unchanged on return.
+func beatable(t *testing.T, ai Player, b *Board, turn, aiMark Mark) bool {
+	t.Helper()
+	if w, ok := b.Winner(); ok {
+		return w != aiMark
+	}
+	if b.IsFull() {
+		return false
+	}
+	moves := b.AvailableMoves()
+	if turn == aiMark {
+		mv, err := ai.Move(context.Background(), b, turn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		moves = []int{mv}
+	}
+	for _, mv := range moves {
+		if err := b.MakeMove(mv, turn); err != nil {
+			t.Fatal(err)
+		}
+		lost := beatable(t, ai, b, b.switchMark(turn), aiMark)
+		_ = b.Undo()
+		if lost {
+			return true
+		}
+	}
+	return false
+}
+
+func TestMinimaxDepth(t *testing.T) {
+	// Depth 0 looks one ply ahead, at the position after the AI's own move.
+	tests := []struct {
+		depth int
+		mark  Mark
+		want  bool
+	}{
+		{0, O, true},
+		{9, X, false},
+		{9, O, false},
+	}
+	for _, tt := range tests {
+		ai := NewMinimaxDepth("AI", tt.depth)
+		if got := beatable(t, ai, NewBoard(), X, tt.mark); got != tt.want {
+			t.Errorf("depth %d as %c: beatable = %v, want %v", tt.depth, tt.mark, got, tt.want)
+		}
+	}
+}
+
+// scriptedPlayer plays the given cells in order and errors once they run out.
+type scriptedPlayer struct {
+	name  string
+	moves []int
+}
+
+func (s *scriptedPlayer) Name() string { return s.name }
+
+func (s *scriptedPlayer) Move(ctx context.Context, b *Board, mark Mark) (int, error) {
+	if len(s.moves) == 0 {
+		return -1, errors.New("script exhausted")
+	}
+	mv := s.moves[0]
+	s.moves = s.moves[1:]
+	return mv, nil
+}
+
+func TestScoreboardRecord(t *testing.T) {
+	games := []struct {
+		x, o           string
+		xMoves, oMoves []int
+	}{
+		{"A", "B", []int{0, 1, 2}, []int{3, 4}},
+		{"A", "B", []int{0, 2, 3, 7, 8}, []int{1, 4, 5, 6}}, // draw
+		{"B", "A", []int{0, 1, 2}, []int{3, 4}},
+	}
+	s := NewScoreboard()
+	for i, gm := range games {
+		g := NewGame(&scriptedPlayer{gm.x, gm.xMoves}, &scriptedPlayer{gm.o, gm.oMoves})
+		res, err := g.PlayHeadless()
+		if err != nil {
+			t.Fatalf("game %d: %v", i+1, err)
+		}
+		if err := s.Record(g, res.Winner); err != nil {
+			t.Fatalf("game %d: %v", i+1, err)
+		}
+	}
+	if s.Wins("A") != 1 || s.Wins("B") != 1 || s.Draws() != 1 {
+		t.Errorf("got %s, want one win each and one draw", s.Summary())
+	}
+}
+
+func TestPlayHeadlessMinimaxNeverLoses(t *testing.T) {
+	for seed := int64(0); seed < 20; seed++ {
+		for _, aiMark := range []Mark{X, O} {
+			random, ai := Player(NewRandomSeeded("Random", seed)), Player(NewMinimax("AI"))
+			g := NewGame(random, ai)
+			if aiMark == X {
+				g = NewGame(ai, random)
+			}
+			res, err := g.PlayHeadless()
+			if err != nil {
+				t.Fatalf("seed %d: %v", seed, err)
+			}
+			if res.Winner != Empty && res.Winner != aiMark {
+				t.Errorf("seed %d: AI as %c lost:\n%s", seed, aiMark, g.Board().String())
+			}
+			if res.NumMoves != len(res.Moves) {
+				t.Errorf("seed %d: NumMoves = %d, but %d moves", seed, res.NumMoves, len(res.Moves))
+			}
+		}
+	}
+}
+
+func TestWinnerLine(t *testing.T) {
+	tests := []struct {
+		name         string
+		size, winLen int
+		mark         Mark
+		cells        []int
+	}{
+		{"top row", 3, 3, X, []int{0, 1, 2}},
+		{"column", 3, 3, O, []int{1, 4, 7}},
+		{"4x4 diagonal of three", 4, 3, O, []int{5, 10, 15}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewBoardN(tt.size, tt.winLen)
+			for _, idx := range tt.cells {
+				_ = b.MakeMove(idx, tt.mark)
+			}
+			m, line, ok := b.WinnerLine()
+			if !ok || m != tt.mark || !slices.Equal(line, tt.cells) {
+				t.Errorf("WinnerLine() = %c, %v, %v; want %c, %v, true", m, line, ok, tt.mark, tt.cells)
+			}
+		})
+	}
+	if _, _, ok := NewBoard().WinnerLine(); ok {
+		t.Error("WinnerLine() on an empty board reported a win")
+	}
+}
+
+// treeNodes counts the positions a search of b to depth plies visits with
+// neither a transposition table nor pruning, current being the side to move.
+func treeNodes(b *Board, current Mark, depth int) int {
+	n := 1
+	if _, won := b.Winner(); won || b.IsFull() || depth == 0 {
+		return n
+	}
+	for _, mv := range b.AvailableMoves() {
+		_ = b.MakeMove(mv, current)
+		n += treeNodes(b, b.switchMark(current), depth-1)
+		_ = b.UnmakeMove(mv)
+	}
+	return n
+}
+
+// uncachedNodes counts the positions MinimaxAI.Move would search from b
+// without its transposition table: the tree below each distinct candidate.
+func uncachedNodes(b *Board, mark Mark, depth int) int {
+	n := 0
+	seen := make(map[string]bool)
+	for _, mv := range b.AvailableMoves() {
+		nb := b.Clone()
+		_ = nb.MakeMove(mv, mark)
+		if key := nb.CanonicalForm().hash(mark); !seen[key] {
+			seen[key] = true
+			n += treeNodes(nb, nb.switchMark(mark), depth)
+		}
+	}
+	return n
+}
+
+// cachedSearch4x4 returns an AI that searches the empty 4x4 board to a fixed
+// depth without pruning, so only the transposition table saves nodes.
+func cachedSearch4x4() *MinimaxAI {
+	ai := NewMinimaxDepth("AI", 4)
+	ai.NoPruning = true
+	return ai
+}
+
+func TestTranspositionTableSavesNodes(t *testing.T) {
+	ai := cachedSearch4x4()
+	if _, err := ai.Move(context.Background(), NewBoardN(4, 4), X); err != nil {
+		t.Fatal(err)
+	}
+	uncached := uncachedNodes(NewBoardN(4, 4), X, ai.MaxDepth)
+	if ai.LastSearchNodes >= uncached {
+		t.Errorf("searched %d nodes, no fewer than %d without the table", ai.LastSearchNodes, uncached)
+	}
+}
+
+func BenchmarkTranspositionTable4x4(bm *testing.B) {
+	ai := cachedSearch4x4()
+	benchmarkMove(bm, ai, NewBoardN(4, 4), X)
+	bm.ReportMetric(float64(ai.LastSearchNodes), "nodes/op")
+	bm.ReportMetric(float64(uncachedNodes(NewBoardN(4, 4), X, ai.MaxDepth)), "uncached-nodes/op")
+}
+
+func TestMinimaxSearchesDistinctOpenings(t *testing.T) {
+	var out strings.Builder
+	ai := NewMinimax("AI")
+	ai.Verbose, ai.Out = true, &out
+	mv, err := ai.Move(context.Background(), NewBoard(), X)
+	if err != nil {
+		t.Fatal(err)
+	}
+	scored, searched := 0, 0
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		if !strings.Contains(line, "score") {
+			continue
+		}
+		scored++
+		if !strings.Contains(line, "(symmetric)") {
+			searched++
+		}
+	}
+	if scored != 9 || searched != 3 {
+		t.Errorf("scored %d openings, searched %d; want 9 and 3 (corner, edge, center):\n%s", scored, searched, out.String())
+	}
+	if err := NewBoard().CanMove(mv); err != nil {
+		t.Errorf("Move() = %d: %v", mv, err)
+	}
+}
+
+func TestNetworkPlayerOverPipe(t *testing.T) {
+	local, remote := net.Pipe()
+	defer local.Close()
+	replies := []string{"abc", "9", "4"}
+	go func() {
+		defer remote.Close()
+		r := bufio.NewReader(remote)
+		for _, reply := range replies {
+			// Wait for the prompt that follows the board.
+			for {
+				line, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if strings.Contains(line, "enter move") {
+					break
+				}
+			}
+			if _, err := remote.Write([]byte(reply + "\n")); err != nil {
+				return
+			}
+		}
+	}()
+
+	p := NewNetworkPlayer("remote", local)
+	b := NewBoard()
+	for _, reply := range replies[:2] {
+		if mv, err := p.Move(context.Background(), b, X); err == nil {
+			t.Errorf("reply %q: Move() = %d, want an error", reply, mv)
+		}
+	}
+	if mv, err := p.Move(context.Background(), b, X); err != nil || mv != 4 {
+		t.Errorf("Move() = %d, %v; want 4, nil", mv, err)
+	}
+}
+
+func TestExportAndReplay(t *testing.T) {
+	g := NewGame(NewRandomSeeded("Random", 3), NewMinimax("AI"))
+	res, err := g.PlayHeadless()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pgn := g.ExportPGNLike()
+	if first := res.Moves[0]; !strings.Contains(pgn, fmt.Sprintf("1. %c@%d", first.Mark, first.Index)) {
+		t.Errorf("transcript lacks the first move %v:\n%s", first, pgn)
+	}
+	r, err := ReplayFrom(res.Moves)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.Board().Equal(g.Board()) {
+		t.Errorf("replay reached\n%s\nwant\n%s", r.Board().String(), g.Board().String())
+	}
+}
+
+func TestReplayFromRejectsIllegalMoves(t *testing.T) {
+	tests := []struct {
+		name  string
+		moves []Move
+	}{
+		{"occupied cell", []Move{{0, X}, {0, O}}},
+		{"out of turn", []Move{{0, O}}},
+		{"off the board", []Move{{9, X}}},
+		{"after the win", []Move{{0, X}, {3, O}, {1, X}, {4, O}, {2, X}, {5, O}}},
+	}
+	for _, tt := range tests {
+		if _, err := ReplayFrom(tt.moves); err == nil {
+			t.Errorf("%s: ReplayFrom succeeded", tt.name)
+		}
+	}
+}
+
+func TestMinimaxMoveCancelled(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	b := NewBoardN(4, 4)
+	start := time.Now()
+	mv, err := NewMinimax("AI").Move(ctx, b, X)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("Move took %v after a 50ms deadline", elapsed)
+	}
+	// Either nothing was scored in time or the best move so far is legal.
+	if err == nil {
+		if err := b.CanMove(mv); err != nil {
+			t.Errorf("partial result %d: %v", mv, err)
+		}
+	}
+}
+
+func TestHumanMoveCancelled(t *testing.T) {
+	in, w := io.Pipe()
+	h := NewHumanIO("Human", in, io.Discard)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := h.Move(ctx, NewBoard(), X); err != context.DeadlineExceeded {
+		t.Fatalf("Move() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	// The line typed after giving up is not lost.
+	go w.Write([]byte("3\n"))
+	if mv, err := h.Move(context.Background(), NewBoard(), X); mv != 3 || err != nil {
+		t.Errorf("Move() = %d, %v; want 3, nil", mv, err)
+	}
+}
+
+func TestServerGame(t *testing.T) {
+	srv := httptest.NewServer(NewServer())
+	defer srv.Close()
+
+	// do sends a request and decodes the game state it returns.
+	do := func(method, path, body string, wantStatus int) gameState {
+		t.Helper()
+		req, err := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != wantStatus {
+			t.Fatalf("%s %s: status %d, want %d", method, path, resp.StatusCode, wantStatus)
+		}
+		var st gameState
+		if wantStatus < 300 {
+			if err := json.NewDecoder(resp.Body).Decode(&st); err != nil {
+				t.Fatalf("%s %s: %v", method, path, err)
+			}
+		}
+		return st
+	}
+
+	id := do(http.MethodPost, "/game", "", http.StatusCreated).ID
+	do(http.MethodPost, "/game/"+id+"/move", `{"index":4}`, http.StatusOK)
+	do(http.MethodPost, "/game/"+id+"/move", `{"index":0}`, http.StatusOK)
+	do(http.MethodPost, "/game/"+id+"/move", `{"index":0}`, http.StatusBadRequest)
+	st := do(http.MethodGet, "/game/"+id, "", http.StatusOK)
+	if st.Board.At(4) != X || st.Board.At(0) != O || st.Turn != "X" {
+		t.Errorf("got turn %q and board\n%s", st.Turn, st.Board.String())
+	}
+	do(http.MethodGet, "/game/99", "", http.StatusNotFound)
+}
+
+// TestSyncBoardConcurrentAccess is meant for go test -race: it reads Winner
+// from several goroutines while another plays moves.
+func TestSyncBoardConcurrentAccess(t *testing.T) {
+	sb := NewSyncBoard(NewBoardN(5, 5))
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+					sb.Winner()
+				}
+			}
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 25; i++ {
+			if err := sb.MakeMove(i, X); err != nil {
+				t.Error(err)
+			}
+		}
+		close(stop)
+	}()
+	wg.Wait()
+	if w, ok := sb.Winner(); !ok || w != X {
+		t.Errorf("Winner() = %c, %v; want X, true", w, ok)
+	}
+}
+
+// mustParse is ParseBoard for known-good layouts.
+func mustParse(t *testing.T, s string) *Board {
+	t.Helper()
+	b, err := ParseBoard(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestHeuristicScoreFavoursThreats(t *testing.T) {
+	ai := NewMinimax("AI")
+	ai.me = X
+	threat := mustParse(t, "XX./.O./..O") // X wins next at 2
+	neutral := mustParse(t, "X../.../..O")
+	if got, base := ai.heuristicScore(threat), ai.heuristicScore(neutral); got <= base {
+		t.Errorf("heuristicScore one move from a win = %v, want above %v", got, base)
+	}
+	if s := ai.heuristicScore(threat); s <= -1 || s >= 1 {
+		t.Errorf("heuristicScore = %v, want inside (-1, 1)", s)
+	}
+}
+
+func TestMinimaxWinsOrBlocks(t *testing.T) {
+	tests := []struct {
+		name  string
+		board string
+		mark  Mark
+		want  int
+	}{
+		{"immediate win", "XX./OO./...", X, 2},
+		{"win before block", "XX./OO./X..", O, 5},
+		{"forced block", "O../.X./O..", X, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ai := NewMinimax("AI")
+			mv, err := ai.Move(context.Background(), mustParse(t, tt.board), tt.mark)
+			if err != nil || mv != tt.want {
+				t.Fatalf("Move() = %d, %v; want %d", mv, err, tt.want)
+			}
+			if ai.LastSearchNodes != 0 {
+				t.Errorf("searched %d nodes, want none", ai.LastSearchNodes)
+			}
+		})
+	}
+}
+
+func TestParseBoard(t *testing.T) {
+	for _, s := range []string{"X.O/.X./O.X", "X.O\n.X.\nO.X\n", " X . O  . X .  O . X "} {
+		b, err := ParseBoard(s)
+		if err != nil {
+			t.Fatalf("ParseBoard(%q): %v", s, err)
+		}
+		for i, want := range "X.O.X.O.X" {
+			m := Mark(want)
+			if m == '.' {
+				m = Empty
+			}
+			if got := b.At(i); got != m {
+				t.Errorf("ParseBoard(%q): cell %d = %q, want %q", s, i, got, m)
+			}
+		}
+	}
+}
+
+func TestParseBoardErrors(t *testing.T) {
+	tests := []struct {
+		layout string
+		strict bool // only ParseBoardStrict rejects it
+	}{
+		{"X.O", false},
+		{"X.O/.X./O.X/.", false},
+		{"X.Z/.../...", false},
+		{"XXX/XX./...", true}, // X far ahead
+		{"XXX/OOO/X..", true}, // both have a line
+	}
+	for _, tt := range tests {
+		_, err := ParseBoard(tt.layout)
+		if got := err != nil; got == tt.strict {
+			t.Errorf("ParseBoard(%q) error = %v", tt.layout, err)
+		}
+		if _, err := ParseBoardStrict(tt.layout); err == nil {
+			t.Errorf("ParseBoardStrict(%q) succeeded", tt.layout)
+		}
+	}
+}
+
+func TestBestLineEndsInWin(t *testing.T) {
+	tests := []struct {
+		name  string
+		board string
+		mark  Mark
+	}{
+		{"one move from a win", "XX./OO./...", X},
+		{"fork wins in three", "XO./.X./..O", X},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := mustParse(t, tt.board)
+			line, score := NewMinimax("AI").BestLine(b, tt.mark)
+			if score != 1 || len(line) == 0 {
+				t.Fatalf("BestLine() = %v, %v; want a winning line", line, score)
+			}
+			turn := tt.mark
+			for _, mv := range line {
+				if err := b.MakeMove(mv, turn); err != nil {
+					t.Fatalf("line %v: %v", line, err)
+				}
+				turn = b.switchMark(turn)
+			}
+			if w, ok := b.Winner(); !ok || w != tt.mark {
+				t.Errorf("line %v ends with\n%s", line, b.String())
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		board   string
+		wantErr bool
+	}{
+		{"balanced", "XX./OO./...", false},
+		{"X too far ahead", "XX./X../...", true},
+		{"O ahead", "O../.../...", true},
+		{"both have a line", "XXX/OOO/X..", true},
+		{"moved after the win", "XXX/OO./..O", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := mustParse(t, tt.board)
+			if err := b.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() = %v, want error %v", err, tt.wantErr)
+			}
+			if _, err := NewGameFromBoard(b, X, nil, nil); err == nil && tt.wantErr {
+				t.Error("NewGameFromBoard accepted the board")
+			}
+		})
+	}
+}
+
+func TestHumanUndo(t *testing.T) {
+	h := NewHumanIO("Human", strings.NewReader("0\nundo\n4\n"), io.Discard)
+	g := NewGame(h, &scriptedPlayer{"Script", []int{1, 2}})
+	for i := 0; i < 4; i++ { // X, O, undo, X
+		if _, _, err := g.Step(); err != nil {
+			t.Fatalf("step %d: %v", i+1, err)
+		}
+	}
+	b := g.Board()
+	if b.At(0) != Empty || b.At(1) != Empty || b.At(4) != X || g.CurrentMark() != O {
+		t.Errorf("after the undo got %c to move on\n%s", g.CurrentMark(), b.String())
+	}
+}
+
+func TestHumanRowColInput(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    int
+		wantErr bool
+	}{
+		{"2,0", 6, false},
+		{"1 2", 5, false},
+		{" 0 , 1 ", 1, false},
+		{"7", 7, false},
+		{"3,0", 0, true},
+		{"0,-1", 0, true},
+		{"a,b", 0, true},
+		{"1 2 3", 0, true},
+	}
+	for _, tt := range tests {
+		h := NewHumanIO("Human", strings.NewReader(tt.input+"\n"), io.Discard)
+		mv, err := h.Move(context.Background(), NewBoard(), X)
+		switch {
+		case tt.wantErr && err == nil:
+			t.Errorf("input %q: Move() = %d, want an error", tt.input, mv)
+		case !tt.wantErr && (err != nil || mv != tt.want):
+			t.Errorf("input %q: Move() = %d, %v; want %d", tt.input, mv, err, tt.want)
+		}
+	}
+}
+
+func TestMinimaxVerboseListsEveryMove(t *testing.T) {
+	var out strings.Builder
+	ai := NewMinimax("AI")
+	ai.Verbose, ai.Out = true, &out
+	b := mustParse(t, "X../.O./...")
+	if _, err := ai.Move(context.Background(), b, X); err != nil {
+		t.Fatal(err)
+	}
+	for _, mv := range b.AvailableMoves() {
+		if !strings.Contains(out.String(), fmt.Sprintf("move %d: score ", mv)) {
+			t.Errorf("no score for move %d in:\n%s", mv, out.String())
+		}
+	}
+}
+
+func TestCustomMarks(t *testing.T) {
+	g := NewGameWithMarks(&scriptedPlayer{"A", []int{0, 1, 2}}, &scriptedPlayer{"B", []int{3, 4}}, 'A', 'B')
+	res, err := g.PlayHeadless()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Winner != 'A' {
+		t.Errorf("winner %q, want 'A'", res.Winner)
+	}
+	if w, ok := g.Board().Winner(); !ok || w != 'A' {
+		t.Errorf("Winner() = %q, %v; want 'A', true", w, ok)
+	}
+	if s := g.Board().String(); !strings.Contains(s, "A") || !strings.Contains(s, "B") {
+		t.Errorf("String() lacks the marks:\n%s", s)
+	}
+	if m := g.Board().switchMark('A'); m != 'B' {
+		t.Errorf("switchMark('A') = %q, want 'B'", m)
+	}
+}
+
+func TestIsDrawInevitable(t *testing.T) {
+	tests := []struct {
+		board string
+		want  bool
+	}{
+		{"XOX/XOO/OX.", true},
+		{"XOX/.O./...", false},
+		{".../.../...", false},
+		{"XXX/OO./...", false}, // won, not drawn
+	}
+	for _, tt := range tests {
+		if got := IsDrawInevitable(mustParse(t, tt.board)); got != tt.want {
+			t.Errorf("IsDrawInevitable(%q) = %v, want %v", tt.board, got, tt.want)
+		}
+	}
+}
+
+func TestRandomSeededRepeatable(t *testing.T) {
+	a, b := NewRandomSeeded("A", 42), NewRandomSeeded("B", 42)
+	board := midgameBoard()
+	for i := 0; i < 20; i++ {
+		x, errX := a.Move(context.Background(), board, O)
+		y, errY := b.Move(context.Background(), board, O)
+		if errX != nil || errY != nil || x != y {
+			t.Fatalf("move %d: %d, %v and %d, %v", i+1, x, errX, y, errY)
+		}
+	}
+}
+
+func TestTournamentMinimaxUnbeaten(t *testing.T) {
+	players := []Player{NewMinimax("AI"), NewRandomSeeded("Random", 1)}
+	res := RunTournament(context.Background(), players, 5)
+	if res.Games != 10 || res.Errors != 0 {
+		t.Fatalf("%d games and %d errors, want 10 and 0", res.Games, res.Errors)
+	}
+	ai := res.Records[0]
+	if ai.Losses != 0 || ai.Wins+ai.Draws != ai.Games() {
+		t.Errorf("AI record %+v, want no losses", ai)
+	}
+	if top := res.Standings()[0]; top.Name != "AI" {
+		t.Errorf("standings led by %s, want AI", top.Name)
+	}
+}
+
+func TestRotateAndMirror(t *testing.T) {
+	b := NewBoard()
+	_ = b.MakeMove(0, X)
+	_ = b.MakeMove(5, O)
+	r := b
+	for i := 0; i < 4; i++ {
+		r = r.Rotate90()
+	}
+	if !r.Equal(b) {
+		t.Errorf("four rotations gave\n%s", r.String())
+	}
+	if m := b.Mirror().Mirror(); !m.Equal(b) {
+		t.Errorf("mirroring twice gave\n%s", m.String())
+	}
+	if r := b.Rotate90(); r.At(2) != X || r.At(7) != O {
+		t.Errorf("Rotate90 gave\n%s", r.String())
+	}
+	if b.At(0) != X || b.At(2) != Empty {
+		t.Errorf("transforms changed the original:\n%s", b.String())
+	}
+}
+
+func TestRowColIndex(t *testing.T) {
+	tests := []struct {
+		size, idx, row, col int
+	}{
+		{3, 0, 0, 0},
+		{3, 2, 0, 2},
+		{3, 3, 1, 0},
+		{3, 8, 2, 2},
+		{4, 3, 0, 3},
+		{4, 4, 1, 0},
+		{4, 9, 2, 1},
+		{4, 15, 3, 3},
+	}
+	for _, tt := range tests {
+		b := NewBoardN(tt.size, 3)
+		if r, c := b.RowCol(tt.idx); r != tt.row || c != tt.col {
+			t.Errorf("%dx%d RowCol(%d) = %d, %d; want %d, %d", tt.size, tt.size, tt.idx, r, c, tt.row, tt.col)
+		}
+		if i := b.Index(tt.row, tt.col); i != tt.idx {
+			t.Errorf("%dx%d Index(%d, %d) = %d, want %d", tt.size, tt.size, tt.row, tt.col, i, tt.idx)
+		}
+	}
+}
+
+func TestRowColIndexPanicOffBoard(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   func(b *Board)
+	}{
+		{"RowCol(-1)", func(b *Board) { b.RowCol(-1) }},
+		{"RowCol(9)", func(b *Board) { b.RowCol(9) }},
+		{"Index(3, 0)", func(b *Board) { b.Index(3, 0) }},
+		{"Index(0, -1)", func(b *Board) { b.Index(0, -1) }},
+	}
+	for _, tt := range tests {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s did not panic", tt.name)
+				}
+			}()
+			tt.fn(NewBoard())
+		}()
+	}
+}
+
+func TestStepPlaysFullGame(t *testing.T) {
+	g := NewGame(NewRandomSeeded("A", 1), NewRandomSeeded("B", 2))
+	var winner Mark
+	for steps := 0; ; steps++ {
+		if steps > 9 {
+			t.Fatal("game did not end within 9 steps")
+		}
+		if want := []string{"A", "B"}[steps%2]; g.CurrentPlayer().Name() != want {
+			t.Fatalf("step %d: CurrentPlayer() = %s, want %s", steps+1, g.CurrentPlayer().Name(), want)
+		}
+		done, w, err := g.Step()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if done {
+			winner = w
+			break
+		}
+	}
+	before := g.MoveCount()
+	if done, w, err := g.Step(); !done || w != winner || err != nil || g.MoveCount() != before {
+		t.Errorf("Step() after the end = %v, %c, %v", done, w, err)
+	}
+}
+
+func TestSaveAndLoadGame(t *testing.T) {
+	g := NewGame(NewRandomSeeded("A", 1), NewRandomSeeded("B", 2))
+	for i := 0; i < 3; i++ {
+		if _, _, err := g.Step(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	path := filepath.Join(t.TempDir(), "game.json")
+	if err := g.SaveGame(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadGame(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !loaded.Board().Equal(g.Board()) || loaded.CurrentMark() != g.CurrentMark() {
+		t.Errorf("loaded %c to move on\n%s", loaded.CurrentMark(), loaded.Board().String())
+	}
+	if len(loaded.MoveLog()) != 3 || loaded.CurrentPlayer().Name() != "B" {
+		t.Errorf("loaded %d moves with %s to play", len(loaded.MoveLog()), loaded.CurrentPlayer().Name())
+	}
+	loaded.SetPlayers(NewRandomSeeded("A", 3), NewRandomSeeded("B", 4))
+	if _, _, err := loaded.Step(); err != nil {
+		t.Errorf("Step after SetPlayers: %v", err)
+	}
+}
+
+func TestLoadGameErrors(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, data string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	tests := []struct {
+		name string
+		path string
+	}{
+		{"missing file", filepath.Join(dir, "missing.json")},
+		{"corrupt JSON", write("corrupt.json", "{")},
+		{"history disagrees with board", write("history.json",
+			`{"size":3,"win_len":3,"marks":["X","O"],"board":["X",".",".",".",".",".",".",".","."],"history":[{"index":1,"mark":"X"}],"current":"O"}`)},
+		{"repeated cell", write("repeat.json",
+			`{"size":3,"win_len":3,"marks":["X","O"],"board":["X",".",".",".",".",".",".",".","."],"history":[{"index":0,"mark":"X"},{"index":0,"mark":"X"}],"current":"O"}`)},
+		{"out of turn", write("turn.json",
+			`{"size":3,"win_len":3,"marks":["X","O"],"board":["X",".",".",".","O",".",".",".","."],"history":[{"index":4,"mark":"O"},{"index":0,"mark":"X"}],"current":"X"}`)},
+	}
+	for _, tt := range tests {
+		if _, err := LoadGame(tt.path); err == nil {
+			t.Errorf("%s: LoadGame succeeded", tt.name)
+		}
+	}
+}
+
+// plainMinimax is the two-branch minimax MinimaxAI used before negamax, kept
+// as a reference: it scores b for ai.me with current to move, maximizing on
+// the AI's turns, and copies the board for every move.
+func plainMinimax(ai *MinimaxAI, b *Board, current Mark, maximizing bool, depth int) float64 {
+	if score, terminal := ai.evaluate(b); terminal {
+		return score
+	}
+	if depth == 0 {
+		return ai.heuristicScore(b)
+	}
+	best := math.Inf(1)
+	if maximizing {
+		best = math.Inf(-1)
+	}
+	for _, mv := range b.AvailableMoves() {
+		nb := b.Clone()
+		_ = nb.MakeMove(mv, current)
+		score := plainMinimax(ai, nb, b.switchMark(current), !maximizing, depth-1)
+		if maximizing && score > best || !maximizing && score < best {
+			best = score
+		}
+	}
+	return best
+}
+
+// plainMove is the move the old implementation chose: an immediate win, else
+// a block, else the lowest-indexed move with the best minimax score.
+func plainMove(ai *MinimaxAI, b *Board, mark Mark) int {
+	ai.me = mark
+	if mv, ok := findWinningMove(b, mark); ok {
+		return mv
+	}
+	if mv, ok := findWinningMove(b, b.switchMark(mark)); ok {
+		return mv
+	}
+	best, bestMove := math.Inf(-1), -1
+	for _, mv := range b.AvailableMoves() {
+		nb := b.Clone()
+		_ = nb.MakeMove(mv, mark)
+		if score := plainMinimax(ai, nb, b.switchMark(mark), false, ai.MaxDepth); score > best {
+			best, bestMove = score, mv
+		}
+	}
+	return bestMove
+}
+
+// randomPositions returns n unfinished 3x3 positions reached by random play,
+// each with the side to move.
+func randomPositions(n int, seed int64) ([]*Board, []Mark) {
+	rng := NewRandomSeeded("Random", seed)
+	var boards []*Board
+	var marks []Mark
+	for i := 0; len(boards) < n; i++ {
+		b, turn := NewBoard(), X
+		for ply := 0; ply < i%7; ply++ {
+			if _, over := b.Winner(); over || b.IsFull() {
+				break
+			}
+			mv, _ := rng.Move(context.Background(), b, turn)
+			_ = b.MakeMove(mv, turn)
+			turn = b.switchMark(turn)
+		}
+		if _, over := b.Winner(); over || b.IsFull() {
+			continue
+		}
+		boards, marks = append(boards, b), append(marks, turn)
+	}
+	return boards, marks
+}
+
+func TestNegamaxMatchesMinimax(t *testing.T) {
+	boards, marks := randomPositions(100, 7)
+	for i, b := range boards {
+		for _, depth := range []int{-1, 0, 1, 2} {
+			ai := NewMinimaxDepth("AI", depth)
+			ai.TieBreak = TieBreakFirst
+			want := plainMove(ai, b, marks[i])
+			got, err := ai.Move(context.Background(), b, marks[i])
+			if err != nil || got != want {
+				t.Fatalf("depth %d, %c to move on\n%s\nMove() = %d, %v; want %d", depth, marks[i], b.String(), got, err, want)
+			}
+		}
+	}
+}
+
+func TestGameAccessors(t *testing.T) {
+	g := NewGame(NewRandomSeeded("A", 3), NewRandomSeeded("B", 4))
+	for i := 0; i < 4; i++ {
+		if g.MoveCount() != i || g.CurrentMark() != []Mark{X, O}[i%2] {
+			t.Fatalf("after %d moves: MoveCount() = %d, CurrentMark() = %c", i, g.MoveCount(), g.CurrentMark())
+		}
+		if _, over := g.IsOver(); over {
+			t.Fatalf("IsOver() after %d moves", i)
+		}
+		if _, _, err := g.Step(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestAnalyze(t *testing.T) {
+	tests := []struct {
+		board     string
+		toMove    Mark
+		wantMove  int
+		wantScore float64
+	}{
+		{"XX./OO./...", X, 2, 1},
+		{"XX./OO./X..", O, 5, 1},
+		{"X../.../...", O, 4, 0},
+	}
+	for _, tt := range tests {
+		mv, score, err := Analyze(mustParse(t, tt.board), tt.toMove)
+		if err != nil || mv != tt.wantMove || score != tt.wantScore {
+			t.Errorf("Analyze(%q, %c) = %d, %v, %v; want %d, %v", tt.board, tt.toMove, mv, score, err, tt.wantMove, tt.wantScore)
+		}
+	}
+	for _, s := range []string{"XXX/OO./...", "XOX/XOO/OXX"} {
+		if _, _, err := Analyze(mustParse(t, s), O); err == nil {
+			t.Errorf("Analyze(%q) on a finished game succeeded", s)
+		}
+	}
+}
+
+func TestScriptedHumanGame(t *testing.T) {
+	var out strings.Builder
+	// The human tries every cell in turn; taken ones are asked again.
+	h := NewHumanIO("Human", strings.NewReader("0\n1\n2\n3\n4\n5\n6\n7\n8\n"), &out)
+	g := NewGame(h, NewRandomSeeded("Random", 5))
+	g.Out = &out
+	w, err := g.Play()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "Draw"
+	if w != Empty {
+		want = fmt.Sprintf("Winner: %c", w)
+	}
+	if !strings.Contains(out.String(), want) {
+		t.Errorf("output lacks %q:\n%s", want, out.String())
+	}
+}
+
+// alphaBetaNodes runs a plain alpha-beta search of b, trying moves in the
+// order next gives, and returns the score for current, the side to move, and
+// the number of positions visited.
+func alphaBetaNodes(b *Board, current Mark, alpha, beta float64, next func(*Board) []int) (float64, int) {
+	if _, won := b.Winner(); won {
+		return -1, 1 // the previous player completed the line
+	}
+	if b.IsFull() {
+		return 0, 1
+	}
+	best, nodes := math.Inf(-1), 1
+	for _, mv := range next(b) {
+		_ = b.MakeMove(mv, current)
+		score, n := alphaBetaNodes(b, b.switchMark(current), -beta, -alpha, next)
+		_ = b.UnmakeMove(mv)
+		nodes += n
+		best = math.Max(best, -score)
+		alpha = math.Max(alpha, best)
+		if alpha >= beta {
+			break
+		}
+	}
+	return best, nodes
+}
+
+var moveOrders = []struct {
+	name string
+	next func(*Board) []int
+}{
+	{"ordered", (*Board).orderedMoves},
+	{"index order", (*Board).AvailableMoves},
+}
+
+// openingNodes counts the positions alpha-beta visits scoring X's opening.
+func openingNodes(next func(*Board) []int) int {
+	score, nodes := alphaBetaNodes(NewBoard(), X, math.Inf(-1), math.Inf(1), next)
+	if score != 0 {
+		panic(fmt.Sprintf("opening scored %v, want a draw", score))
+	}
+	return nodes
+}
+
+func TestMoveOrderingPrunesMore(t *testing.T) {
+	ordered, unordered := openingNodes(moveOrders[0].next), openingNodes(moveOrders[1].next)
+	if ordered >= unordered {
+		t.Errorf("ordered search visited %d nodes, index order %d", ordered, unordered)
+	}
+	if mv := NewBoard().orderedMoves()[0]; mv != 4 {
+		t.Errorf("first ordered move %d, want the center", mv)
+	}
+}
+
+func BenchmarkMoveOrderingOpening(bm *testing.B) {
+	for _, order := range moveOrders {
+		bm.Run(order.name, func(bm *testing.B) {
+			nodes := 0
+			for i := 0; i < bm.N; i++ {
+				nodes = openingNodes(order.next)
+			}
+			bm.ReportMetric(float64(nodes), "nodes/op")
+		})
+	}
+}
+
+func TestAIPlayingXMovesFirst(t *testing.T) {
+	human, ai := NewRandomSeeded("Human", 11), NewMinimax("AI")
+	px, po := seatPlayers(human, ai, O)
+	if px != Player(ai) || po != Player(human) {
+		t.Fatalf("seatPlayers for a human O gave X=%s, O=%s", px.Name(), po.Name())
+	}
+	g := NewGame(px, po)
+	if _, _, err := g.Step(); err != nil {
+		t.Fatal(err)
+	}
+	if first := g.MoveLog()[0]; first.Mark != X || first.Index%2 != 0 {
+		t.Errorf("AI opened with %v, want a corner or the center", first)
+	}
+	if beatable(t, ai, NewBoard(), X, X) {
+		t.Error("AI playing X can be beaten")
+	}
+}
+
+func TestParseConfig(t *testing.T) {
+	tests := []struct {
+		args    []string
+		want    config
+		wantErr bool
+	}{
+		{nil, config{Mode: "hvai", AIDepth: -1, Size: 3}, false},
+		{[]string{"-mode", "aivai", "-ai-depth", "2", "-size", "4", "-seed", "9"},
+			config{Mode: "aivai", AIDepth: 2, Size: 4, Seed: 9}, false},
+		{[]string{"-mode=hvh", "-clear"}, config{Mode: "hvh", AIDepth: -1, Size: 3, Clear: true}, false},
+		{[]string{"-mode", "xx"}, config{}, true},
+		{[]string{"-size", "2"}, config{}, true},
+		{[]string{"-size", "three"}, config{}, true},
+		{[]string{"extra"}, config{}, true},
+	}
+	for _, tt := range tests {
+		cfg, err := parseConfig(tt.args)
+		if (err != nil) != tt.wantErr || cfg != tt.want {
+			t.Errorf("parseConfig(%q) = %+v, %v; want %+v", tt.args, cfg, err, tt.want)
+		}
+	}
+}
+
+func TestSimulatePerfectPlayersDraw(t *testing.T) {
+	w1, w2, draws := SimulateAIvsAI(NewMinimax("AI 1"), NewMinimax("AI 2"), 4)
+	if w1 != 0 || w2 != 0 || draws != 4 {
+		t.Errorf("SimulateAIvsAI = %d, %d, %d; want 0, 0, 4", w1, w2, draws)
+	}
+}
+
+// cornerEvaluator ends the search at once, scoring +1 for holding cell 0 and
+// counting how often it is consulted.
+type cornerEvaluator struct{ calls int }
+
+func (e *cornerEvaluator) Evaluate(b *Board, me Mark) (float64, bool) {
+	e.calls++
+	if b.At(0) == me {
+		return 1, true
+	}
+	return 0, true
+}
+
+func TestMinimaxUsesEvaluator(t *testing.T) {
+	eval := &cornerEvaluator{}
+	ai := NewMinimax("AI")
+	ai.Evaluator = eval
+	mv, err := ai.Move(context.Background(), NewBoard(), X)
+	if err != nil || mv != 0 {
+		t.Errorf("Move() = %d, %v; want the corner the evaluator favours", mv, err)
+	}
+	if eval.calls == 0 {
+		t.Error("evaluator was never consulted")
+	}
+}
+
+func TestPlayEndsWhenInputCloses(t *testing.T) {
+	g := NewGame(NewHumanIO("Human", strings.NewReader(""), io.Discard), NewRandomSeeded("Random", 1))
+	g.Out = io.Discard
+	done := make(chan error, 1)
+	go func() {
+		_, err := g.Play()
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if !errors.Is(err, ErrInputClosed) {
+			t.Errorf("Play() error = %v, want %v", err, ErrInputClosed)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Play did not return after the input closed")
+	}
+}
+
+func TestOpenLines(t *testing.T) {
+	tests := []struct {
+		board string
+		x, o  int
+	}{
+		{".../.../...", 8, 8},
+		{"XO./.X./O..", 3, 2},
+		{"XOX/XOO/OX.", 0, 0},
+	}
+	for _, tt := range tests {
+		b := mustParse(t, tt.board)
+		if x, o := b.OpenLines(X), b.OpenLines(O); x != tt.x || o != tt.o {
+			t.Errorf("%q: OpenLines = %d for X, %d for O; want %d and %d", tt.board, x, o, tt.x, tt.o)
+		}
+	}
+}
+
+func TestColorRendering(t *testing.T) {
+	b := midgameBoard()
+	colored, plain := b.Render(true), b.Render(false)
+	for _, seq := range []string{ansiX, ansiO, ansiReset} {
+		if !strings.Contains(colored, seq) {
+			t.Errorf("colored output lacks %q:\n%q", seq, colored)
+		}
+	}
+	if strings.Contains(plain, "\x1b[") {
+		t.Errorf("plain output has escape codes:\n%q", plain)
+	}
+	if b.ColorString() != colored {
+		t.Error("ColorString disagrees with Render(true)")
+	}
+}
+
+func TestStringWithHints(t *testing.T) {
+	b := NewBoard()
+	_ = b.MakeMove(0, X)
+	_ = b.MakeMove(4, O)
+	want := "X | 1 | 2\n---------\n3 | O | 5\n---------\n6 | 7 | 8"
+	if got := b.StringWithHints(); got != want {
+		t.Errorf("StringWithHints() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestHumanForfeit(t *testing.T) {
+	h := NewHumanIO("Human", strings.NewReader("q\n"), io.Discard)
+	g := NewGame(h, NewRandomSeeded("Random", 1))
+	res, err := g.PlayHeadless()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Winner != O || res.NumMoves != 0 {
+		t.Errorf("winner %q after %d moves, want O after none", res.Winner, res.NumMoves)
+	}
+}
+
+func TestStringWithLastMove(t *testing.T) {
+	b := NewBoard()
+	_ = b.MakeMove(0, X)
+	_ = b.MakeMove(4, O)
+	want := " X | . | . \n-----------\n . |[O]| . \n-----------\n . | . | . "
+	if got := b.StringWithLastMove(); got != want {
+		t.Errorf("StringWithLastMove() =\n%s\nwant\n%s", got, want)
+	}
+	_ = b.Undo()
+	want = "[X]| . | . \n-----------\n . | . | . \n-----------\n . | . | . "
+	if got := b.StringWithLastMove(); got != want {
+		t.Errorf("after Undo, StringWithLastMove() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestWinLenOnLargeBoard(t *testing.T) {
+	tests := []struct {
+		name  string
+		cells []int
+	}{
+		{"vertical", []int{8, 15, 22, 29, 36}},       // column 1, rows 1-5
+		{"anti-diagonal", []int{12, 18, 24, 30, 36}}, // (1,5) to (5,1)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := NewBoardN(7, 5)
+			for i, idx := range tt.cells {
+				if _, ok := b.Winner(); ok {
+					t.Fatalf("win after %d marks", i)
+				}
+				_ = b.MakeMove(idx, O)
+			}
+			m, line, ok := b.WinnerLine()
+			if !ok || m != O || !slices.Equal(line, tt.cells) {
+				t.Errorf("WinnerLine() = %c, %v, %v; want O, %v", m, line, ok, tt.cells)
+			}
+		})
+	}
+}
+
+func TestIterativeDeepeningTinyBudget(t *testing.T) {
+	b := NewBoardN(5, 4)
+	start := time.Now()
+	mv, err := NewIterativeDeepening("AI", time.Millisecond).Move(context.Background(), b, X)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Move took %v on a 1ms budget", elapsed)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := b.CanMove(mv); err != nil {
+		t.Errorf("Move() = %d: %v", mv, err)
+	}
+}
+
+func TestBoardEqualAndHash(t *testing.T) {
+	a, b := midgameBoard(), NewBoard()
+	for _, mv := range []Move{{8, X}, {0, O}, {4, X}} { // same cells, other order
+		_ = b.MakeMove(mv.Index, mv.Mark)
+	}
+	if !a.Equal(b) || a.Hash() != b.Hash() {
+		t.Errorf("identical boards: Equal = %v, hashes %x and %x", a.Equal(b), a.Hash(), b.Hash())
+	}
+	_ = b.MakeMove(1, O)
+	if a.Equal(b) || a.Hash() == b.Hash() {
+		t.Errorf("boards a cell apart: Equal = %v, hashes %x and %x", a.Equal(b), a.Hash(), b.Hash())
+	}
+	if a.Equal(NewBoardN(4, 3)) {
+		t.Error("boards of different sizes are Equal")
+	}
+}
+
+// stubRenderer draws every board as the same marker.
+type stubRenderer struct{}
+
+func (stubRenderer) Render(b *Board) string { return "<board>" }
+
+func TestRenderers(t *testing.T) {
+	b := NewBoard()
+	_ = b.MakeMove(0, X)
+	_ = b.MakeMove(4, O)
+	tests := []struct {
+		r    Renderer
+		want string
+	}{
+		{PlainRenderer{}, "X | . | .\n---------\n. | O | .\n---------\n. | . | ."},
+		{NumberedRenderer{}, "X | 1 | 2\n---------\n3 | O | 5\n---------\n6 | 7 | 8"},
+		{ColorRenderer{}, b.Render(true)},
+	}
+	for _, tt := range tests {
+		if got := tt.r.Render(b); got != tt.want {
+			t.Errorf("%T.Render() =\n%s\nwant\n%s", tt.r, got, tt.want)
+		}
+	}
+
+	var out strings.Builder
+	g := NewGame(NewHumanIO("Human", strings.NewReader("q\n"), io.Discard), NewRandomSeeded("Random", 1))
+	g.Out, g.Renderer = &out, stubRenderer{}
+	if _, err := g.Play(); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "<board>") {
+		t.Errorf("Play ignored the Renderer:\n%s", out.String())
+	}
+}
+
+func TestReplayForwardAndBack(t *testing.T) {
+	moves := []Move{{4, X}, {0, O}, {8, X}, {2, O}, {1, X}}
+	r, err := NewReplay(moves)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := NewBoard()
+	for i, mv := range moves {
+		_ = want.MakeMove(mv.Index, mv.Mark)
+		b, ok := r.Forward()
+		if !ok || !b.Equal(want) {
+			t.Fatalf("Forward %d: %v\n%s", i+1, ok, b.String())
+		}
+	}
+	if _, ok := r.Forward(); ok || r.Ply() != len(moves) {
+		t.Errorf("Forward past the end: %v at ply %d", ok, r.Ply())
+	}
+	for i := len(moves) - 1; i >= 0; i-- {
+		_ = want.Undo()
+		b, ok := r.Back()
+		if !ok || !b.Equal(want) {
+			t.Fatalf("Back to ply %d: %v\n%s", i, ok, b.String())
+		}
+	}
+	if b, ok := r.Back(); ok || !b.Equal(NewBoard()) {
+		t.Errorf("Back past the start: %v\n%s", ok, b.String())
+	}
+	if _, err := NewReplay([]Move{{0, X}, {0, O}}); err == nil {
+		t.Error("NewReplay accepted an illegal game")
+	}
+}
+
+func TestCountForks(t *testing.T) {
+	tests := []struct {
+		board string
+		mark  Mark
+		want  int
+	}{
+		{"X../.O./..X", X, 2}, // corners 2 and 6
+		{".../.../...", X, 0},
+		{"X../.O./..X", O, 0},
+	}
+	for _, tt := range tests {
+		if got := CountForks(mustParse(t, tt.board), tt.mark); got != tt.want {
+			t.Errorf("CountForks(%q, %c) = %d, want %d", tt.board, tt.mark, got, tt.want)
+		}
+	}
+}
+
+func TestOpeningBook(t *testing.T) {
+	book := make(OpeningBook)
+	book.Add(NewBoard(), 8)
+	path := filepath.Join(t.TempDir(), "book.json")
+	if err := book.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadOpeningBook(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ai := NewMinimax("AI")
+	ai.Book = loaded
+	if mv, err := ai.Move(context.Background(), NewBoard(), X); err != nil || mv != 8 {
+		t.Errorf("Move() = %d, %v; want the booked 8", mv, err)
+	}
+	if ai.LastSearchNodes != 0 {
+		t.Errorf("searched %d nodes for a booked move", ai.LastSearchNodes)
+	}
+}
+
+// sleepyPlayer takes d to choose each move, then plays like Random.
+type sleepyPlayer struct {
+	*RandomPlayer
+	d time.Duration
+}
+
+func (s sleepyPlayer) Move(ctx context.Context, b *Board, mark Mark) (int, error) {
+	time.Sleep(s.d)
+	return s.RandomPlayer.Move(ctx, b, mark)
+}
+
+func TestMoveTimings(t *testing.T) {
+	const d = 20 * time.Millisecond
+	g := NewGame(sleepyPlayer{NewRandomSeeded("Sleepy", 1), d}, NewRandomSeeded("Random", 2))
+	for i := 0; i < 2; i++ {
+		if _, _, err := g.Step(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	timings := g.MoveTimings()
+	if len(timings) != 2 || timings[0] < d {
+		t.Errorf("MoveTimings() = %v, want two with the first at least %v", timings, d)
+	}
+}
+
+func TestHandicapSkipsTopMove(t *testing.T) {
+	boards, marks := randomPositions(50, 11)
+	for i, b := range boards {
+		if len(b.AvailableMoves()) < 2 {
+			continue
+		}
+		top, err := NewMinimax("AI").Move(context.Background(), b, marks[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		ai := NewMinimax("AI")
+		ai.Handicap, ai.Rand = 1, rand.New(rand.NewSource(int64(i)))
+		if mv, err := ai.Move(context.Background(), b, marks[i]); err != nil || mv == top {
+			t.Fatalf("%c to move on\n%s\nhandicapped Move() = %d, %v; top move %d", marks[i], b.String(), mv, err, top)
+		}
+	}
+}
+
+func TestOnMoveCallbacks(t *testing.T) {
+	g := NewGame(&scriptedPlayer{"A", []int{0, 1, 2}}, &scriptedPlayer{"B", []int{3, 4}})
+	var seen []Move
+	calls := 0
+	g.OnMove(func(mark Mark, index int, board *Board) {
+		if board.At(index) != mark {
+			t.Errorf("callback for %c@%d before the move was applied", mark, index)
+		}
+		seen = append(seen, Move{index, mark})
+	})
+	g.OnMove(func(Mark, int, *Board) { calls++ })
+	res, err := g.PlayHeadless()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(seen, res.Moves) || calls != len(res.Moves) {
+		t.Errorf("callbacks saw %v and %d calls, want %v", seen, calls, res.Moves)
+	}
+}
+
+func TestMaxHistory(t *testing.T) {
+	b := NewBoard()
+	b.SetMaxHistory(2)
+	for _, mv := range []Move{{0, X}, {4, O}, {8, X}, {2, O}} {
+		_ = b.MakeMove(mv.Index, mv.Mark)
+	}
+	for i := 0; i < 2; i++ {
+		if err := b.Undo(); err != nil {
+			t.Fatalf("Undo %d: %v", i+1, err)
+		}
+	}
+	if err := b.Undo(); err == nil {
+		t.Error("third Undo beyond a cap of 2 succeeded")
+	}
+	if b.At(0) != X || b.At(4) != O || b.At(8) != Empty || b.At(2) != Empty {
+		t.Errorf("after undoing to the cap:\n%s", b.String())
+	}
+}
+
+func TestWinningMoves(t *testing.T) {
+	tests := []struct {
+		board  string
+		toMove Mark
+		want   []int
+	}{
+		{"XO./.X./..O", X, []int{3, 6}}, // each sets up a fork
+		{"XX./OO./...", O, []int{2, 5}}, // 2 blocks and still wins
+		{".../.../...", X, nil},
+		{"XXX/OO./...", O, nil},
+	}
+	for _, tt := range tests {
+		if got := WinningMoves(mustParse(t, tt.board), tt.toMove); !slices.Equal(got, tt.want) {
+			t.Errorf("WinningMoves(%q, %c) = %v, want %v", tt.board, tt.toMove, got, tt.want)
+		}
+	}
+}
+
+func TestSentinelErrors(t *testing.T) {
+	full := mustParse(t, "XOX/XOO/OXX")
+	tests := []struct {
+		name string
+		err  func() error
+		want error
+	}{
+		{"double move", func() error {
+			b := NewBoard()
+			_ = b.MakeMove(4, X)
+			return b.MakeMove(4, O)
+		}, ErrCellOccupied},
+		{"off the board", func() error { return NewBoard().MakeMove(9, X) }, ErrOutOfBounds},
+		{"human types a word", func() error {
+			_, err := NewHumanIO("Human", strings.NewReader("abc\n"), io.Discard).Move(context.Background(), NewBoard(), X)
+			return err
+		}, ErrInvalidNumber},
+		{"human picks a taken cell", func() error {
+			_, err := NewHumanIO("Human", strings.NewReader("0\n"), io.Discard).Move(context.Background(), full, X)
+			return err
+		}, ErrCellOccupied},
+		{"random player on a full board", func() error {
+			_, err := NewRandomSeeded("Random", 1).Move(context.Background(), full, X)
+			return err
+		}, ErrNoMoves},
+	}
+	for _, tt := range tests {
+		if err := tt.err(); !errors.Is(err, tt.want) {
+			t.Errorf("%s: error %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestEncodeDecodeGame(t *testing.T) {
+	four := NewGameN(nil, nil, 4, 3)
+	_ = four.applyMove(5)
+	g, err := NewGameFromBoard(midgameBoard(), O, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		g    *Game
+		want string
+	}{
+		{g, "O...X...X o"},
+		{four, ".....X.......... o 3"},
+	}
+	for _, tt := range tests {
+		s := tt.g.Encode()
+		if s != tt.want {
+			t.Errorf("Encode() = %q, want %q", s, tt.want)
+		}
+		d, err := DecodeGame(s)
+		if err != nil {
+			t.Fatalf("DecodeGame(%q): %v", s, err)
+		}
+		if !d.Board().Equal(tt.g.Board()) || d.CurrentMark() != tt.g.CurrentMark() || d.Board().WinLen() != tt.g.Board().WinLen() {
+			t.Errorf("DecodeGame(%q) gave %c to move on\n%s", s, d.CurrentMark(), d.Board().String())
+		}
+	}
+	for _, s := range []string{"", "X........", "X........ x", "XX....... o", "X.......Z o", "X........ o 4"} {
+		if _, err := DecodeGame(s); err == nil {
+			t.Errorf("DecodeGame(%q) succeeded", s)
+		}
+	}
+}
+
+func TestBestDefensiveMove(t *testing.T) {
+	tests := []struct {
+		board string
+		mark  Mark
+		want  int
+	}{
+		{"O../.X./O..", X, 3}, // the only block
+		{"XX./.O./...", O, 2},
+	}
+	for _, tt := range tests {
+		if mv, ok := BestDefensiveMove(mustParse(t, tt.board), tt.mark); !ok || mv != tt.want {
+			t.Errorf("BestDefensiveMove(%q, %c) = %d, %v; want %d", tt.board, tt.mark, mv, ok, tt.want)
+		}
+	}
+	if _, ok := BestDefensiveMove(mustParse(t, "XOX/XOO/OXX"), X); ok {
+		t.Error("BestDefensiveMove on a full board reported a move")
+	}
+}
+
+func TestParsePlayAgain(t *testing.T) {
+	tests := []struct {
+		answer    string
+		wantSize  int
+		wantAgain bool
+		wantErr   bool
+	}{
+		{"y\n", 3, true, false},
+		{"YES", 3, true, false},
+		{"play 4\n", 4, true, false},
+		{"n\n", 3, false, false},
+		{"", 3, false, false},
+		{"play 2", 3, false, true},
+		{"play four", 3, false, true},
+	}
+	for _, tt := range tests {
+		size, again, err := parsePlayAgain(tt.answer, 3)
+		if size != tt.wantSize || again != tt.wantAgain || (err != nil) != tt.wantErr {
+			t.Errorf("parsePlayAgain(%q) = %d, %v, %v; want %d, %v", tt.answer, size, again, err, tt.wantSize, tt.wantAgain)
+		}
+	}
+}
+
+func TestThreats(t *testing.T) {
+	b := mustParse(t, "O.X/.X./O..")
+	if got := b.Threats(O); !slices.Equal(got, []int{3}) {
+		t.Errorf("Threats(O) = %v, want [3]", got)
+	}
+	if got := b.Threats(X); len(got) != 0 {
+		t.Errorf("Threats(X) = %v, want none", got)
+	}
+}
+
+func TestNewGameFromBoard(t *testing.T) {
+	b := mustParse(t, "XX./OO./...")
+	g, err := NewGameFromBoard(b, X, NewMinimax("AI"), NewRandomSeeded("Random", 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	done, w, err := g.Step()
+	if err != nil || !done || w != X || g.Board().At(2) != X {
+		t.Errorf("first Step() = %v, %c, %v on\n%s", done, w, err, g.Board().String())
+	}
+	if b.At(2) != Empty {
+		t.Error("the game played on the caller's board")
+	}
+	if _, err := NewGameFromBoard(b, O, nil, nil); err == nil {
+		t.Error("NewGameFromBoard accepted the wrong side to move")
+	}
+}
+
+func TestClearScreen(t *testing.T) {
+	for _, clear := range []bool{false, true} {
+		var out strings.Builder
+		g := NewGame(&scriptedPlayer{"A", []int{0, 1, 2}}, &scriptedPlayer{"B", []int{3, 4}})
+		g.Out, g.ClearScreen = &out, clear
+		if _, err := g.Play(); err != nil {
+			t.Fatal(err)
+		}
+		if got := strings.Count(out.String(), ansiClear); (got > 0) != clear {
+			t.Errorf("ClearScreen %v: %d clear sequences", clear, got)
+		}
+	}
+}
+
+func TestNewPlayerByName(t *testing.T) {
+	p, err := NewPlayerByName("minimax:depth=5,name=Bot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ai, ok := p.(*MinimaxAI)
+	if !ok || ai.MaxDepth != 5 || ai.Name() != "Bot" {
+		t.Errorf("got %#v, want a depth-5 MinimaxAI named Bot", p)
+	}
+	for _, spec := range []string{"random", "random:seed=3", "human", "deepening:budget=10ms"} {
+		if _, err := NewPlayerByName(spec); err != nil {
+			t.Errorf("NewPlayerByName(%q): %v", spec, err)
+		}
+	}
+	for _, spec := range []string{"alien", "minimax:depth=deep", "minimax:depth", "random:colour=red", "deepening:budget=0s"} {
+		if _, err := NewPlayerByName(spec); err == nil {
+			t.Errorf("NewPlayerByName(%q) succeeded", spec)
+		}
+	}
+}
+
+func TestCanMove(t *testing.T) {
+	b := midgameBoard()
+	before := b.String()
+	tests := []struct {
+		idx  int
+		want error
+	}{
+		{1, nil},
+		{4, ErrCellOccupied},
+		{-1, ErrOutOfBounds},
+		{9, ErrOutOfBounds},
+	}
+	for _, tt := range tests {
+		if err := b.CanMove(tt.idx); !errors.Is(err, tt.want) {
+			t.Errorf("CanMove(%d) = %v, want %v", tt.idx, err, tt.want)
+		}
+	}
+	if b.String() != before || len(b.history) != 3 {
+		t.Errorf("CanMove changed the board:\n%s", b.String())
+	}
+}
+
+func TestTieBreak(t *testing.T) {
+	tests := []struct {
+		tb   TieBreak
+		want int
+	}{
+		{TieBreakCenter, 4},
+		{TieBreakFirst, 0},
+	}
+	for _, tt := range tests {
+		ai := NewMinimax("AI")
+		ai.TieBreak = tt.tb
+		if mv, err := ai.Move(context.Background(), NewBoard(), X); err != nil || mv != tt.want {
+			t.Errorf("TieBreak %d: Move() = %d, %v; want %d", tt.tb, mv, err, tt.want)
+		}
+	}
+	ai := NewMinimax("AI")
+	ai.TieBreak, ai.Rand = TieBreakRandom, rand.New(rand.NewSource(1))
+	seen := make(map[int]bool)
+	for i := 0; i < 30; i++ {
+		mv, err := ai.Move(context.Background(), NewBoard(), X)
+		if err != nil {
+			t.Fatal(err)
+		}
+		seen[mv] = true
+	}
+	if len(seen) < 2 {
+		t.Errorf("TieBreakRandom always opened at %v", seen)
+	}
+}
+
+func TestCount(t *testing.T) {
+	b := mustParse(t, "XOX/.O./X..")
+	for m, want := range map[Mark]int{X: 3, O: 2, Empty: 4} {
+		if got := b.Count(m); got != want {
+			t.Errorf("Count(%q) = %d, want %d", m, got, want)
+		}
+	}
+}
+
+func TestInferTurn(t *testing.T) {
+	tests := []struct {
+		board   string
+		want    Mark
+		wantErr bool
+	}{
+		{".../.../...", X, false},
+		{"X.O/.../...", X, false},
+		{"X../.../...", O, false},
+		{"XX./.../...", Empty, true},
+		{"O../.../...", Empty, true},
+	}
+	for _, tt := range tests {
+		got, err := mustParse(t, tt.board).InferTurn()
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("InferTurn(%q) = %q, %v; want %q", tt.board, got, err, tt.want)
+		}
+	}
+}
+
+func TestAnalyzeInput(t *testing.T) {
+	var out strings.Builder
+	if err := analyzeInput(strings.NewReader("XX.\nOO.\n...\n"), &out); err != nil {
+		t.Fatal(err)
+	}
+	want := "X to move: best move 2 (row 0, col 2), score 1 (X wins)\n"
+	if out.String() != want {
+		t.Errorf("analyzeInput wrote %q, want %q", out.String(), want)
+	}
+	for _, in := range []string{"XX./...", "XX./X../...", "XXX/OO./..."} {
+		if err := analyzeInput(strings.NewReader(in), &out); err == nil {
+			t.Errorf("analyzeInput(%q) succeeded", in)
+		}
+	}
+}
+
+func TestThreePlayerGame(t *testing.T) {
+	marks := []Mark{X, O, 'T'}
+	for seed := int64(0); seed < 10; seed++ {
+		players := []Player{NewRandomSeeded("A", seed), NewRandomSeeded("B", seed+100), NewRandomSeeded("C", seed+200)}
+		g := NewMultiGame(players, marks, 5, 4)
+		res, err := g.PlayHeadless()
+		if err != nil {
+			t.Fatalf("seed %d: %v", seed, err)
+		}
+		for i, mv := range res.Moves {
+			if mv.Mark != marks[i%3] {
+				t.Fatalf("seed %d: move %d played by %c, want %c", seed, i+1, mv.Mark, marks[i%3])
+			}
+		}
+		if w, line, ok := g.Board().WinnerLine(); ok != (res.Winner != Empty) || ok && (w != res.Winner || len(line) != 4) {
+			t.Errorf("seed %d: winner %q but WinnerLine() = %q, %v, %v", seed, res.Winner, w, line, ok)
+		}
+		if res.Winner != Empty && !slices.Contains(marks, res.Winner) {
+			t.Errorf("seed %d: winner %q is not a player", seed, res.Winner)
+		}
+	}
+
+	g := NewMultiGame([]Player{NewMinimax("AI"), NewRandom("B"), NewRandom("C")}, marks, 5, 4)
+	if _, _, err := g.Step(); !errors.Is(err, ErrTwoPlayersOnly) {
+		t.Errorf("minimax in a three-player game: %v, want %v", err, ErrTwoPlayersOnly)
+	}
+}
+
+func TestHumanHint(t *testing.T) {
+	var out strings.Builder
+	h := NewHumanIO("Human", strings.NewReader("hint\n2\n"), &out)
+	g, err := NewGameFromBoard(midgameBoard(), O, nil, h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := NewMinimax("AI").Move(context.Background(), midgameBoard(), O)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := g.Step(); err != nil {
+		t.Fatal(err)
+	}
+	if hint := fmt.Sprintf("Hint: play %d", want); !strings.Contains(out.String(), hint) {
+		t.Errorf("output lacks %q:\n%s", hint, out.String())
+	}
+	if g.Board().At(2) != O || g.MoveCount() != 4 {
+		t.Errorf("after the hint got\n%s", g.Board().String())
+	}
+}
+
+func TestGameResultSnapshots(t *testing.T) {
+	g := NewGame(NewRandomSeeded("A", 1), NewRandomSeeded("B", 2))
+	res, err := g.PlayHeadless()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Snapshots) != res.NumMoves {
+		t.Fatalf("%d snapshots for %d moves", len(res.Snapshots), res.NumMoves)
+	}
+	if last := res.Snapshots[len(res.Snapshots)-1]; !last.Equal(g.Board()) {
+		t.Errorf("last snapshot\n%s\nwant the final board\n%s", last.String(), g.Board().String())
+	}
+	for i, s := range res.Snapshots {
+		if n := s.Count(Empty); n != 9-(i+1) {
+			t.Errorf("snapshot %d has %d free cells", i, n)
+		}
+	}
+	// Clearing the first move from one snapshot must not touch the others.
+	first := res.Moves[0]
+	_ = res.Snapshots[0].Set(first.Index, Empty)
+	if res.Snapshots[1].At(first.Index) != first.Mark || g.Board().At(first.Index) != first.Mark {
+		t.Error("snapshots share cells")
+	}
+}
+
+func TestLastSearchNodesPruning(t *testing.T) {
+	pruned, plain := NewMinimax("AI"), NewMinimax("AI")
+	plain.NoPruning = true
+	for _, ai := range []*MinimaxAI{pruned, plain} {
+		if _, err := ai.Move(context.Background(), NewBoard(), X); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if pruned.LastSearchNodes <= 0 || pruned.LastSearchNodes >= plain.LastSearchNodes {
+		t.Errorf("searched %d nodes with pruning and %d without", pruned.LastSearchNodes, plain.LastSearchNodes)
+	}
+	if _, err := pruned.Move(context.Background(), mustParse(t, "XX./OO./..."), X); err != nil || pruned.LastSearchNodes != 0 {
+		t.Errorf("immediate win searched %d nodes, %v", pruned.LastSearchNodes, err)
+	}
+}
+
+// cancellingPlayer plays like Random but cancels its tournament when asked to
+// open its second game.
+type cancellingPlayer struct {
+	*RandomPlayer
+	cancel context.CancelFunc
+	games  int
+}
+
+func (c *cancellingPlayer) Move(ctx context.Context, b *Board, mark Mark) (int, error) {
+	if b.Count(Empty) >= len(b.cells)-1 { // its first move of a game
+		c.games++
+		if c.games == 2 {
+			c.cancel()
+			return -1, ctx.Err()
+		}
+	}
+	return c.RandomPlayer.Move(ctx, b, mark)
+}
+
+func TestRunTournamentCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	p := &cancellingPlayer{RandomPlayer: NewRandomSeeded("A", 1), cancel: cancel}
+	res := RunTournament(ctx, []Player{p, NewRandomSeeded("B", 2)}, 10)
+	if res.Games != 1 || res.Errors != 0 {
+		t.Errorf("%d games and %d errors, want exactly one completed game", res.Games, res.Errors)
+	}
+}
+
+func TestNewGameRandomStart(t *testing.T) {
+	a, b := NewRandom("A"), NewRandom("B")
+	firsts := func(seed int64) []string {
+		rng := rand.New(rand.NewSource(seed))
+		names := make([]string, 200)
+		for i := range names {
+			names[i] = NewGameRandomStart(a, b, rng).CurrentPlayer().Name()
+		}
+		return names
+	}
+	names := firsts(5)
+	countA := 0
+	for _, n := range names {
+		if n == "A" {
+			countA++
+		}
+	}
+	if countA < 80 || countA > 120 {
+		t.Errorf("A played X in %d of %d games", countA, len(names))
+	}
+	if again := firsts(5); !slices.Equal(again, names) {
+		t.Error("the same seed gave a different sequence")
+	}
+}
+
+func TestEach(t *testing.T) {
+	for _, b := range []*Board{NewBoard(), NewBoardMN(3, 4, 3)} {
+		_ = b.MakeMove(1, X)
+		next := 0
+		b.Each(func(idx, row, col int, m Mark) {
+			if idx != next || row != idx/b.Cols() || col != idx%b.Cols() || m != b.At(idx) {
+				t.Errorf("%dx%d: visited %d at (%d, %d) with %q, want cell %d", b.Rows(), b.Cols(), idx, row, col, m, next)
+			}
+			next++
+		})
+		if next != b.Rows()*b.Cols() {
+			t.Errorf("%dx%d: visited %d cells", b.Rows(), b.Cols(), next)
+		}
+	}
+}
+
+func TestResult(t *testing.T) {
+	tests := []struct {
+		board  string
+		toMove Mark
+		want   Outcome
+	}{
+		{".../.../...", X, OutcomeInProgress},
+		{"XXX/OO./...", O, OutcomeXWins},
+		{"OOO/XX./X..", X, OutcomeOWins},
+		{"XOX/XOO/OXX", O, OutcomeDraw},
+		{"XOX/XOO/OX.", X, OutcomeDraw}, // nobody can still win
+	}
+	for _, tt := range tests {
+		g, err := NewGameFromBoard(mustParse(t, tt.board), tt.toMove, nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := g.Result(); got != tt.want {
+			t.Errorf("Result() on %q = %d, want %d", tt.board, got, tt.want)
+		}
+	}
+}
+
+func TestUndoTo(t *testing.T) {
+	b, want := NewBoard(), NewBoard()
+	moves := []Move{{4, X}, {0, O}, {8, X}, {2, O}, {1, X}}
+	for i, mv := range moves {
+		_ = b.MakeMove(mv.Index, mv.Mark)
+		if i < 2 {
+			_ = want.MakeMove(mv.Index, mv.Mark)
+		}
+	}
+	if err := b.UndoTo(2); err != nil {
+		t.Fatal(err)
+	}
+	if !b.Equal(want) {
+		t.Errorf("UndoTo(2) gave\n%s\nwant\n%s", b.String(), want.String())
+	}
+	if err := b.Redo(); err != nil || b.At(8) != X {
+		t.Errorf("Redo after UndoTo: %v\n%s", err, b.String())
+	}
+	for _, ply := range []int{-1, 4} {
+		if err := b.UndoTo(ply); err == nil {
+			t.Errorf("UndoTo(%d) with 3 moves succeeded", ply)
+		}
+	}
+}
+
+func TestDiffString(t *testing.T) {
+	before, after := NewBoard(), NewBoard()
+	for _, mv := range []Move{{4, X}, {0, O}, {8, X}, {2, O}} {
+		if mv.Index != 8 && mv.Index != 2 {
+			_ = before.MakeMove(mv.Index, mv.Mark)
+		}
+		_ = after.MakeMove(mv.Index, mv.Mark)
+	}
+	want := " O | . |[O]\n-----------\n . | X | . \n-----------\n . | . |[X]"
+	if got := DiffString(before, after); got != want {
+		t.Errorf("DiffString() =\n%s\nwant\n%s", got, want)
+	}
+	if got := DiffString(before, NewBoardN(4, 3)); !strings.Contains(got, "differ in size") {
+		t.Errorf("DiffString of different sizes = %q", got)
+	}
+}
+
+func TestWeightedRandomFavoursCenter(t *testing.T) {
+	weights := []float64{
+		4, 1, 4,
+		1, 8, 1,
+		4, 1, 4,
+	}
+	p := NewWeightedRandom("Weighted", weights, rand.New(rand.NewSource(1)))
+	counts := make([]int, 9)
+	for i := 0; i < 2000; i++ {
+		mv, err := p.Move(context.Background(), NewBoard(), X)
+		if err != nil {
+			t.Fatal(err)
+		}
+		counts[mv]++
+	}
+	for _, edge := range []int{1, 3, 5, 7} {
+		if counts[4] <= counts[edge] || counts[0] <= counts[edge] {
+			t.Errorf("center %d and corner %d times, edge %d %d times", counts[4], counts[0], edge, counts[edge])
+		}
+	}
+	// Occupied cells are never chosen, whatever their weight.
+	b := mustParse(t, "XOX/.O./...")
+	for i := 0; i < 50; i++ {
+		if mv, err := p.Move(context.Background(), b, X); err != nil || b.CanMove(mv) != nil {
+			t.Fatalf("Move() = %d, %v on\n%s", mv, err, b.String())
+		}
+	}
+}
+
+func TestGenerateTrainingData(t *testing.T) {
+	samples := GenerateTrainingData(30)
+	if len(samples) != 30 {
+		t.Fatalf("got %d samples, want 30", len(samples))
+	}
+	for i, s := range samples {
+		mv, value, err := Analyze(s.Board, s.ToMove)
+		if err != nil || mv != s.Move || value != s.Value {
+			t.Errorf("sample %d: labeled %d (%v), Analyze gives %d, %v, %v", i, s.Move, s.Value, mv, value, err)
+		}
+		if err := s.Board.Validate(); err != nil {
+			t.Errorf("sample %d: %v", i, err)
+		}
+	}
+}
+
+func TestCompletesLine(t *testing.T) {
+	b := mustParse(t, "XX./OO./...")
+	tests := []struct {
+		idx  int
+		mark Mark
+		want bool
+	}{
+		{2, X, true},
+		{5, X, false},
+		{5, O, true},
+		{0, X, false}, // occupied
+		{8, X, false},
+	}
+	for _, tt := range tests {
+		if got := b.CompletesLine(tt.idx, tt.mark); got != tt.want {
+			t.Errorf("CompletesLine(%d, %c) = %v, want %v", tt.idx, tt.mark, got, tt.want)
+		}
+	}
+	if b.At(2) != Empty {
+		t.Error("CompletesLine changed the board")
+	}
+}
+
+func TestCustomMessages(t *testing.T) {
+	msgs := EnglishMessages
+	msgs.Prompt = "%s (%c), votre coup (0-%d) : "
+	msgs.Board = "\nPlateau :\n"
+	msgs.Win = "Gagnant : %c\n"
+	var out, prompts strings.Builder
+	h := NewHumanIO("Humain", strings.NewReader("0\n1\n2\n"), &prompts)
+	h.Messages = &msgs
+	g := NewGame(h, &scriptedPlayer{"B", []int{3, 4}})
+	g.Out, g.Messages = &out, &msgs
+	if _, err := g.Play(); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"Plateau :", "Gagnant : X"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output lacks %q:\n%s", want, out.String())
+		}
+	}
+	if strings.Contains(out.String(), "Winner") {
+		t.Errorf("output still has the English text:\n%s", out.String())
+	}
+	if !strings.Contains(prompts.String(), "Humain (X), votre coup (0-8) : ") {
+		t.Errorf("prompt not translated: %q", prompts.String())
+	}
+	np := NewNetworkPlayer("remote", struct {
+		io.Reader
+		io.Writer
+	}{strings.NewReader("4\n"), &prompts})
+	np.Messages = &msgs
+	if _, err := np.Move(context.Background(), NewBoard(), O); err != nil || !strings.Contains(prompts.String(), "remote (O), votre coup") {
+		t.Errorf("network prompt not translated: %v, %q", err, prompts.String())
+	}
+}
+
+func TestReachableBoards(t *testing.T) {
+	tests := []struct {
+		plies  int
+		reduce bool
+		want   int
+	}{
+		{0, false, 1},
+		{1, true, 3},
+		{1, false, 9},
+		{2, true, 12},
+		{2, false, 72},
+	}
+	for _, tt := range tests {
+		if got := len(ReachableBoards(tt.plies, tt.reduce)); got != tt.want {
+			t.Errorf("ReachableBoards(%d, %v) gave %d boards, want %d", tt.plies, tt.reduce, got, tt.want)
+		}
+	}
+}
+
+func TestRenderWithScores(t *testing.T) {
+	b := mustParse(t, "XX./OO./...")
+	var cells []string
+	for _, line := range strings.Split(RenderWithScores(b, X), "\n") {
+		if strings.Trim(line, "-") == "" {
+			continue
+		}
+		for _, c := range strings.Split(line, "|") {
+			cells = append(cells, strings.TrimSpace(c))
+		}
+	}
+	if len(cells) != 9 || cells[2] != "+1" || cells[0] != "X" || cells[3] != "O" {
+		t.Fatalf("cells %q, want +1 on the winning cell 2", cells)
+	}
+	for _, idx := range b.AvailableMoves() {
+		score, err := strconv.ParseFloat(cells[idx], 64)
+		if err != nil || score > 1 {
+			t.Errorf("cell %d shows %q", idx, cells[idx])
+		}
+	}
+}
+
+func TestMoveOnFinishedBoard(t *testing.T) {
+	players := []Player{NewMinimax("AI"), NewIterativeDeepening("Deepening", 50*time.Millisecond)}
+	for _, s := range []string{"XXX/OO./...", "XOX/XOO/OXX"} {
+		for _, p := range players {
+			if _, err := p.Move(context.Background(), mustParse(t, s), O); !errors.Is(err, ErrGameOver) {
+				t.Errorf("%s on %q: error %v, want %v", p.Name(), s, err, ErrGameOver)
+			}
+		}
+	}
+}
+
+func TestMakeUnmakeMatchesClone(t *testing.T) {
+	rng := NewRandomSeeded("Random", 3)
+	for game := 0; game < 20; game++ {
+		b, turn := NewBoardN(4, 3), X
+		for {
+			if _, over := b.Winner(); over || b.IsFull() {
+				break
+			}
+			ai := NewMinimaxDepth("AI", 2)
+			ai.TieBreak = TieBreakFirst
+			before, history := b.Clone(), slices.Clone(b.history)
+			want := plainMove(ai, b, turn)
+			got, err := ai.Move(context.Background(), b, turn)
+			if err != nil || got != want {
+				t.Fatalf("%c to move on\n%s\nMove() = %d, %v; want %d", turn, b.String(), got, err, want)
+			}
+			if !b.Equal(before) || !slices.Equal(b.history, history) {
+				t.Fatalf("Move changed the board:\n%s\nwas\n%s", b.String(), before.String())
+			}
+			mv, _ := rng.Move(context.Background(), b, turn)
+			_ = b.MakeMove(mv, turn)
+			turn = b.switchMark(turn)
+		}
+	}
+}
+
+func BenchmarkMinimaxAllocs(bm *testing.B) {
+	b := NewBoardN(4, 3)
+	bm.Run("make-unmake", func(bm *testing.B) {
+		ai := NewMinimaxDepth("AI", 3)
+		bm.ReportAllocs()
+		benchmarkMove(bm, ai, b, X)
+	})
+	bm.Run("clone", func(bm *testing.B) {
+		ai := NewMinimaxDepth("AI", 3)
+		bm.ReportAllocs()
+		for i := 0; i < bm.N; i++ {
+			plainMove(ai, b, X)
+		}
+	})
+}
+
+func TestLegalMoveCount(t *testing.T) {
+	boards, _ := randomPositions(30, 11)
+	boards = append(boards, NewBoard(), mustParse(t, "XOX/XOO/OXX"), NewBoardN(4, 3))
+	for _, b := range boards {
+		if got, want := b.LegalMoveCount(), len(b.AvailableMoves()); got != want {
+			t.Errorf("LegalMoveCount() = %d, want %d on\n%s", got, want, b.String())
+		}
+	}
+	b := NewBoard()
+	if allocs := testing.AllocsPerRun(100, func() { b.LegalMoveCount() }); allocs != 0 {
+		t.Errorf("LegalMoveCount allocates %v times", allocs)
+	}
+}
+
+// recordHandler is a slog.Handler that keeps every record it is given.
+type recordHandler struct{ records *[]slog.Record }
+
+func (h recordHandler) Enabled(context.Context, slog.Level) bool { return true }
+func (h recordHandler) Handle(_ context.Context, r slog.Record) error {
+	*h.records = append(*h.records, r)
+	return nil
+}
+func (h recordHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+func (h recordHandler) WithGroup(string) slog.Handler      { return h }
+
+// attr returns the value of r's attribute key as a string.
+func attr(r slog.Record, key string) string {
+	var v string
+	r.Attrs(func(a slog.Attr) bool {
+		if a.Key == key {
+			v = a.Value.String()
+			return false
+		}
+		return true
+	})
+	return v
+}
+
+func TestGameLogsEvents(t *testing.T) {
+	var records []slog.Record
+	g := NewGame(&scriptedPlayer{"A", []int{0, 1, 2}}, &scriptedPlayer{"B", []int{3, 4}})
+	g.Log = slog.New(recordHandler{&records})
+	if _, err := g.PlayHeadless(); err != nil {
+		t.Fatal(err)
+	}
+	var moves []string
+	var over *slog.Record
+	for i, r := range records {
+		switch r.Message {
+		case "move":
+			moves = append(moves, attr(r, "mark")+attr(r, "index"))
+		case "game over":
+			over = &records[i]
+		}
+	}
+	if want := []string{"X0", "O3", "X1", "O4", "X2"}; !slices.Equal(moves, want) {
+		t.Errorf("logged moves %v, want %v", moves, want)
+	}
+	if over == nil {
+		t.Fatal("no game over event")
+	}
+	if attr(*over, "winner") != "X" || attr(*over, "moves") != "5" {
+		t.Errorf("game over event has winner %q after %q moves", attr(*over, "winner"), attr(*over, "moves"))
+	}
+}
+
+func TestPositionValue(t *testing.T) {
+	tests := []struct {
+		board  string
+		toMove Mark
+		want   int
+	}{
+		{".../.../...", X, 0},
+		{"XX./OO./...", X, 1},
+		{"XX./OO./X..", O, 1},
+		{"XO./.X./X.O", O, -1},
+		{"XOX/XOO/OXX", X, 0},
+	}
+	for _, tt := range tests {
+		if got := PositionValue(mustParse(t, tt.board), tt.toMove); got != tt.want {
+			t.Errorf("PositionValue(%q, %c) = %d, want %d", tt.board, tt.toMove, got, tt.want)
+		}
+	}
+}
+
+func TestResignWhenLost(t *testing.T) {
+	// X threatens both 2 and 3, so O loses whatever it plays.
+	b := mustParse(t, "XO./.X./X.O")
+	ai := NewMinimax("AI")
+	ai.ResignWhenLost = true
+	if mv, err := ai.Move(context.Background(), b, O); !errors.Is(err, ErrResign) {
+		t.Fatalf("Move() = %d, %v; want %v", mv, err, ErrResign)
+	}
+	if _, err := NewMinimax("AI").Move(context.Background(), b, O); err != nil {
+		t.Errorf("without ResignWhenLost: %v", err)
+	}
+	g, err := NewGameFromBoard(b, O, NewMinimax("X"), ai)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := g.PlayHeadless()
+	if err != nil || res.Winner != X || g.Board().LegalMoveCount() != 4 {
+		t.Errorf("PlayHeadless() = %+v, %v; want a win for X with no more moves", res, err)
+	}
+}
+
+func TestRectangularBoard(t *testing.T) {
+	b := NewBoardMN(3, 4, 3)
+	if r, c := b.RowCol(7); r != 1 || c != 3 || b.Index(1, 0) != 4 {
+		t.Fatalf("RowCol(7) = %d, %d; Index(1, 0) = %d", r, c, b.Index(1, 0))
+	}
+	// 2 and 3 end row 0 and 4 starts row 1, so these are not a line.
+	for _, idx := range []int{2, 3, 4} {
+		_ = b.MakeMove(idx, X)
+	}
+	if w, over := b.Winner(); over {
+		t.Fatalf("Winner() = %c across a row break", w)
+	}
+	b = NewBoardMN(3, 4, 3)
+	for _, idx := range []int{5, 6, 7} {
+		_ = b.MakeMove(idx, X)
+	}
+	if w, line, over := b.WinnerLine(); !over || w != X || !slices.Equal(line, []int{5, 6, 7}) {
+		t.Errorf("WinnerLine() = %c, %v, %v; want X on 5, 6, 7\n%s", w, line, over, b.String())
+	}
+	want := ". | . | . | .\n-------------\n. | X | X | X\n-------------\n. | . | . | ."
+	if got := b.String(); got != want {
+		t.Errorf("String() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+// randomGame plays p against itself on an empty board and returns the moves.
+func randomGame(p Player) []int {
+	var moves []int
+	b, turn := NewBoard(), X
+	for {
+		if _, over := b.Winner(); over || b.IsFull() {
+			return moves
+		}
+		mv, _ := p.Move(context.Background(), b, turn)
+		_ = b.MakeMove(mv, turn)
+		moves = append(moves, mv)
+		turn = b.switchMark(turn)
+	}
+}
+
+func TestRandomSeedReplays(t *testing.T) {
+	r := NewRandom("Random")
+	seed := r.Seed()
+	want := randomGame(r)
+	if got := randomGame(NewRandomSeeded("Replay", seed)); !slices.Equal(got, want) {
+		t.Errorf("replay with seed %d played %v, want %v", seed, got, want)
+	}
+	if got := NewRandomSeeded("Random", 42).Seed(); got != 42 {
+		t.Errorf("Seed() = %d, want 42", got)
+	}
+}
+
+func TestTranspose(t *testing.T) {
+	b := NewBoard()
+	_ = b.MakeMove(b.Index(0, 1), X)
+	_ = b.MakeMove(b.Index(2, 0), O)
+	tr := b.Transpose()
+	if tr.At(tr.Index(1, 0)) != X || tr.At(tr.Index(0, 1)) != Empty || tr.At(tr.Index(0, 2)) != O {
+		t.Errorf("Transpose() =\n%s", tr.String())
+	}
+	if b.At(b.Index(0, 1)) != X || b.At(b.Index(1, 0)) != Empty {
+		t.Error("Transpose changed the original")
+	}
+	if !tr.Transpose().Equal(b) {
+		t.Errorf("transposing twice gave\n%s", tr.Transpose().String())
+	}
+	rect := NewBoardMN(3, 4, 3)
+	_ = rect.MakeMove(rect.Index(0, 3), X)
+	if tr := rect.Transpose(); tr.Rows() != 4 || tr.Cols() != 3 || tr.At(tr.Index(3, 0)) != X {
+		t.Errorf("Transpose() of a 3x4 board =\n%s", tr.String())
+	}
+}
+
+func TestAtAndSet(t *testing.T) {
+	b := NewBoard()
+	for _, idx := range []int{-1, 9} {
+		if err := b.Set(idx, X); !errors.Is(err, ErrOutOfBounds) {
+			t.Errorf("Set(%d) error %v, want %v", idx, err, ErrOutOfBounds)
+		}
+	}
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("At(9) did not panic")
+			}
+		}()
+		b.At(9)
+	}()
+	_ = b.MakeMove(4, X)
+	if err := b.MakeMove(4, O); !errors.Is(err, ErrCellOccupied) {
+		t.Errorf("MakeMove over X: %v, want %v", err, ErrCellOccupied)
+	}
+	if err := b.Set(4, O); err != nil || b.At(4) != O {
+		t.Errorf("Set(4, O) = %v, cell holds %c", err, b.At(4))
+	}
+	if err := b.Set(4, Empty); err != nil || b.At(4) != Empty {
+		t.Errorf("Set(4, Empty) = %v, cell holds %c", err, b.At(4))
+	}
+	if err := b.Set(0, 'Z'); err == nil {
+		t.Error("Set accepted an unknown mark")
+	}
+}
+
+func TestCountTerminals(t *testing.T) {
+	tests := []struct {
+		board  string
+		toMove Mark
+		want   int
+	}{
+		// O wins on 7, or plays 8 and X fills 7 for a draw.
+		{"XOX/OOX/X..", O, 2},
+		{"XOX/XOO/OXX", X, 1},
+		{".../.../...", X, 255168},
+	}
+	for _, tt := range tests {
+		if got := CountTerminals(mustParse(t, tt.board), tt.toMove); got != tt.want {
+			t.Errorf("CountTerminals(%q, %c) = %d, want %d", tt.board, tt.toMove, got, tt.want)
+		}
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	g := NewGame(&scriptedPlayer{"A", []int{0, 1, 2}}, &scriptedPlayer{"B", []int{3, 4}})
+	ch, other := g.Subscribe(), g.Subscribe()
+	if _, err := g.PlayHeadless(); err != nil {
+		t.Fatal(err)
+	}
+	var boards []*Board
+	for b := range ch {
+		boards = append(boards, b)
+	}
+	if len(boards) != 5 {
+		t.Fatalf("got %d boards, want one per move", len(boards))
+	}
+	for i, b := range boards {
+		if n := 9 - b.LegalMoveCount(); n != i+1 {
+			t.Errorf("board %d has %d marks", i, n)
+		}
+	}
+	if w, _ := boards[4].Winner(); w != X || boards[4] == g.Board() {
+		t.Errorf("last board is not a copy of the won board:\n%s", boards[4].String())
+	}
+	n := 0
+	for range other {
+		n++
+	}
+	if n != 5 {
+		t.Errorf("second subscriber got %d boards", n)
+	}
+}
+
+func TestMisereAvoidsLine(t *testing.T) {
+	b := mustParse(t, "XX./OO./...")
+	if mv, err := NewMinimax("AI").Move(context.Background(), b, X); err != nil || mv != 2 {
+		t.Fatalf("normal AI played %d, %v; want the win on 2", mv, err)
+	}
+	ai := NewMinimax("AI")
+	ai.Misere = true
+	mv, err := ai.Move(context.Background(), b, X)
+	if err != nil || b.CompletesLine(mv, X) {
+		t.Errorf("misère AI played %d, %v, completing a line", mv, err)
+	}
+	g := NewGame(&scriptedPlayer{"A", []int{0, 1, 2}}, &scriptedPlayer{"B", []int{3, 4}})
+	g.Misere = true
+	if res, err := g.PlayHeadless(); err != nil || res.Winner != O {
+		t.Errorf("completing a line in misère: %+v, %v; want O to win", res, err)
+	}
+}
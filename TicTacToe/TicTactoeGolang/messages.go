@@ -0,0 +1,57 @@
+package main
+
+// Messages holds the text Game, Human, NetworkPlayer and the command-line
+// program show to players, so it can be replaced, for instance with a
+// translation. Fields with arguments are fmt formats; their comments list the
+// arguments in order.
+type Messages struct {
+	Prompt  string // player name, mark, highest cell index
+	Hint    string // suggested cell index, its row and column
+	Board   string // heading shown above the board
+	Threat  string // opponent's mark, the cells where it wins next move
+	Invalid string // the error that rejected a move
+	Forfeit string // mark of the player who forfeits
+	Win     string // winner's mark
+	Draw    string
+
+	// Shown by the command-line program around its games.
+	Welcome     string // game mode, board rows and columns
+	ChooseSide  string
+	GameWon     string // winner's mark
+	GameDrawn   string
+	GameError   string // the error that ended the game
+	Score       string // the scoreboard summary
+	PlayAgain   string
+	InputClosed string
+	Goodbye     string
+}
+
+// EnglishMessages is the catalog used when a Game or Human has none set.
+var EnglishMessages = Messages{
+	Prompt:  "%s (%c), enter move (0-%d or row,col): ",
+	Hint:    "Hint: play %d (row %d, col %d)\n",
+	Board:   "\nBoard:\n",
+	Threat:  "Watch out: %c wins next move at %v\n",
+	Invalid: "%v\n",
+	Forfeit: "%c forfeits\n",
+	Win:     "Winner: %c\n",
+	Draw:    "Draw\n",
+
+	Welcome:     "Tic-Tac-Toe - %s on %dx%d\n",
+	ChooseSide:  "Play as X or O? X moves first. (x/o): ",
+	GameWon:     "Game over! Winner: %c\n",
+	GameDrawn:   "Game ended in a draw!\n",
+	GameError:   "Game ended with error: %v\n",
+	Score:       "Score: %s\n",
+	PlayAgain:   "Do you want to play again? (y/n, or play <size>): ",
+	InputClosed: "\nInput closed. Goodbye 👋\n",
+	Goodbye:     "Thanks for playing! Goodbye 👋\n",
+}
+
+// messagesOr returns m, or EnglishMessages if m is nil.
+func messagesOr(m *Messages) *Messages {
+	if m == nil {
+		return &EnglishMessages
+	}
+	return m
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// NetworkPlayer is a remote player reached over a connection such as a
+// net.Conn. On each turn it sends the board and a prompt as text, then reads
+// back one line holding the chosen cell index.
+type NetworkPlayer struct {
+	name   string
+	conn   io.Writer
+	reader *lineReader
+
+	// Messages holds the prompt sent each turn; EnglishMessages if nil.
+	Messages *Messages
+}
+
+func NewNetworkPlayer(name string, conn io.ReadWriter) *NetworkPlayer {
+	return &NetworkPlayer{name: name, conn: conn, reader: newLineReader(conn)}
+}
+
+func (n *NetworkPlayer) Name() string { return n.name }
+
+// Move returns an error for malformed or illegal replies so the game can ask
+// again.
+func (n *NetworkPlayer) Move(ctx context.Context, b *Board, mark Mark) (int, error) {
+	prompt := fmt.Sprintf(messagesOr(n.Messages).Prompt, n.name, mark, len(b.cells)-1)
+	_, err := fmt.Fprintf(n.conn, "%s\n%s\n", b.String(), strings.TrimSpace(prompt))
+	if err != nil {
+		return -1, err
+	}
+	line, err := n.reader.ReadLine(ctx)
+	if err != nil {
+		return -1, err
+	}
+	return parseMove(line, b)
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ParseBoard builds a 3x3 board from a text layout of X, O and . cells in row
+// order. Whitespace and '/' row separators are ignored, so "X.O/.X./O.X" and
+// a three-line grid are both accepted. The position is not checked for
+// reachability; use ParseBoardStrict for that.
+func ParseBoard(s string) (*Board, error) {
+	b := NewBoard()
+	i := 0
+	for _, r := range s {
+		if unicode.IsSpace(r) || r == '/' {
+			continue
+		}
+		m := Mark(r)
+		if m != Empty && !b.isMark(m) {
+			return nil, fmt.Errorf("parse board: illegal character %q", r)
+		}
+		if i < len(b.cells) {
+			_ = b.Set(i, m) // m was checked above
+		}
+		i++
+	}
+	if i != len(b.cells) {
+		return nil, fmt.Errorf("parse board: got %d cells, want %d", i, len(b.cells))
+	}
+	return b, nil
+}
+
+// ParseBoardStrict is like ParseBoard but also rejects positions that cannot
+// arise in a game where X moves first.
+func ParseBoardStrict(s string) (*Board, error) {
+	b, err := ParseBoard(s)
+	if err != nil {
+		return nil, err
+	}
+	if err := b.Validate(); err != nil {
+		return nil, fmt.Errorf("parse board: %w", err)
+	}
+	return b, nil
+}
+
+// ExportPGNLike renders the game as a PGN-style transcript: header tags for
+// the board and result, then one numbered line per round of moves, one move
+// by each player, e.g.
+//
+//	[Size "3"]
+//	[WinLen "3"]
+//	[Result "X"]
+//	1. X@4 O@0
+//	2. X@8
+//
+// Result is "X" or "O" for a win, "draw", or "*" while the game is still
+// running. A rectangular board's size is written as rows by columns, as in
+// [Size "3x4"].
+func (g *Game) ExportPGNLike() string {
+	var sb strings.Builder
+	if g.board.rows == g.board.cols {
+		fmt.Fprintf(&sb, "[Size \"%d\"]\n", g.board.rows)
+	} else {
+		fmt.Fprintf(&sb, "[Size \"%dx%d\"]\n", g.board.rows, g.board.cols)
+	}
+	fmt.Fprintf(&sb, "[WinLen \"%d\"]\n", g.board.winLen)
+	result := "*"
+	if w, over := g.IsOver(); over && w != Empty {
+		result = string(w)
+	} else if over {
+		result = "draw"
+	}
+	fmt.Fprintf(&sb, "[Result \"%s\"]\n", result)
+	players := len(g.board.marks)
+	for i, mv := range g.MoveLog() {
+		if i%players == 0 {
+			if i > 0 {
+				sb.WriteString("\n")
+			}
+			fmt.Fprintf(&sb, "%d.", i/players+1)
+		}
+		fmt.Fprintf(&sb, " %c@%d", mv.Mark, mv.Index)
+	}
+	sb.WriteString("\n")
+	return sb.String()
+}
+
+// ReplayFrom rebuilds a 3x3 game by applying moves in order. Every move must
+// be made by the side to move, on a free cell, before the game is over. The
+// returned game has no players attached.
+func ReplayFrom(moves []Move) (*Game, error) {
+	g := NewGame(nil, nil)
+	for i, mv := range moves {
+		if _, ok := g.board.Winner(); ok {
+			return nil, fmt.Errorf("move %d: game is already over", i+1)
+		}
+		if mv.Mark != g.current {
+			return nil, fmt.Errorf("move %d: %c played out of turn", i+1, mv.Mark)
+		}
+		if err := g.board.MakeMove(mv.Index, mv.Mark); err != nil {
+			return nil, fmt.Errorf("move %d: %w", i+1, err)
+		}
+		g.current = g.board.switchMark(g.current)
+	}
+	return g, nil
+}
+
+// Encode returns a one-line snapshot of the game: the cells in row order, a
+// space and the side to move in lower case, e.g. "X.O.X...O o". If the win
+// length differs from the board size it follows as a third field, as in
+// "................ x 3" for a 4x4 board with three in a row. A rectangular
+// board also has its number of columns as a fourth field. Move history and
+// players are not included.
+func (g *Game) Encode() string {
+	var sb strings.Builder
+	for _, c := range g.board.cells {
+		sb.WriteRune(rune(c))
+	}
+	sb.WriteByte(' ')
+	sb.WriteString(strings.ToLower(string(g.current)))
+	switch {
+	case g.board.rows != g.board.cols:
+		fmt.Fprintf(&sb, " %d %d", g.board.winLen, g.board.cols)
+	case g.board.winLen != g.board.rows:
+		fmt.Fprintf(&sb, " %d", g.board.winLen)
+	}
+	return sb.String()
+}
+
+// DecodeGame rebuilds an X and O game written by Encode. The position must be
+// reachable with X moving first and the side to move must agree with it. The
+// returned game has no players attached.
+func DecodeGame(s string) (*Game, error) {
+	fields := strings.Fields(s)
+	if len(fields) < 2 || len(fields) > 4 {
+		return nil, fmt.Errorf("decode game: want cells and side to move, got %q", s)
+	}
+	cells := []rune(fields[0])
+	rows := int(math.Sqrt(float64(len(cells))))
+	cols := rows
+	if len(fields) == 4 {
+		n, err := strconv.Atoi(fields[3])
+		if err != nil || n < 1 || len(cells)%n != 0 {
+			return nil, fmt.Errorf("decode game: invalid column count %q", fields[3])
+		}
+		rows, cols = len(cells)/n, n
+	}
+	if rows < 1 || rows*cols != len(cells) {
+		return nil, fmt.Errorf("decode game: %d cells is not a square board", len(cells))
+	}
+	winLen := rows
+	if len(fields) >= 3 {
+		n, err := strconv.Atoi(fields[2])
+		if err != nil || n < 1 || n > max(rows, cols) {
+			return nil, fmt.Errorf("decode game: invalid win length %q", fields[2])
+		}
+		winLen = n
+	}
+	b := NewBoardMN(rows, cols, winLen)
+	for i, r := range cells {
+		m := Mark(r)
+		if m != X && m != O && m != Empty {
+			return nil, fmt.Errorf("decode game: illegal character %q", r)
+		}
+		_ = b.Set(i, m) // m was checked above
+	}
+	var toMove Mark
+	switch fields[1] {
+	case "x":
+		toMove = X
+	case "o":
+		toMove = O
+	default:
+		return nil, fmt.Errorf("decode game: invalid side to move %q", fields[1])
+	}
+	g, err := NewGameFromBoard(b, toMove, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("decode game: %w", err)
+	}
+	return g, nil
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+)
+
+// savedGame is the on-disk JSON form of a Game.
+type savedGame struct {
+	Size    int             `json:"size"`
+	Cols    int             `json:"cols,omitempty"` // if not Size, for rectangular boards
+	WinLen  int             `json:"win_len"`
+	Marks   []string        `json:"marks"`
+	Board   json.RawMessage `json:"board"` // decoded once the size is known
+	History []savedMove     `json:"history"`
+	Current string          `json:"current"`
+	Players []string        `json:"players"`
+
+	// PlayerX and PlayerO name the players in files written before games
+	// could have more than two.
+	PlayerX string `json:"player_x,omitempty"`
+	PlayerO string `json:"player_o,omitempty"`
+}
+
+type savedMove struct {
+	Index int    `json:"index"`
+	Mark  string `json:"mark"`
+}
+
+// SaveGame writes the board, move history, side to move and player names to
+// path as JSON.
+func (g *Game) SaveGame(path string) error {
+	cells, err := json.Marshal(g.board)
+	if err != nil {
+		return fmt.Errorf("save game: %w", err)
+	}
+	sg := savedGame{
+		Size:    g.board.rows,
+		WinLen:  g.board.winLen,
+		Marks:   make([]string, len(g.board.marks)),
+		Board:   cells,
+		History: make([]savedMove, len(g.board.history)),
+		Current: string(g.current),
+		Players: make([]string, len(g.players)),
+	}
+	if g.board.cols != g.board.rows {
+		sg.Cols = g.board.cols
+	}
+	for i, m := range g.board.marks {
+		sg.Marks[i] = string(m)
+	}
+	for i, p := range g.players {
+		sg.Players[i] = playerName(p)
+	}
+	for i, mv := range g.board.history {
+		sg.History[i] = savedMove{Index: mv.Index, Mark: string(mv.Mark)}
+	}
+	data, err := json.MarshalIndent(sg, "", "  ")
+	if err != nil {
+		return fmt.Errorf("save game: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("save game: %w", err)
+	}
+	return nil
+}
+
+// LoadGame reads a game written by SaveGame. The position must be reachable
+// and the side to move must agree with it, as for NewGameFromBoard. The
+// history must hold distinct cells played in turn order up to the side to
+// move; it may leave out earlier marks, as in a game started from a position
+// or with a capped history, but those cannot be undone. The loaded game keeps
+// the saved player names, but its players cannot move until the caller binds
+// real ones with SetPlayers.
+func LoadGame(path string) (*Game, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("load game: %w", err)
+	}
+	var sg savedGame
+	if err := json.Unmarshal(data, &sg); err != nil {
+		return nil, fmt.Errorf("load game %s: invalid JSON: %w", path, err)
+	}
+	g, err := sg.restore()
+	if err != nil {
+		return nil, fmt.Errorf("load game %s: %w", path, err)
+	}
+	return g, nil
+}
+
+func (sg *savedGame) restore() (*Game, error) {
+	cols := sg.Size
+	if sg.Cols != 0 {
+		cols = sg.Cols
+	}
+	if sg.Size < 1 || cols < 1 || sg.WinLen < 1 || sg.WinLen > max(sg.Size, cols) {
+		return nil, fmt.Errorf("invalid size %dx%d with win length %d", sg.Size, cols, sg.WinLen)
+	}
+	if len(sg.Marks) < 2 {
+		return nil, fmt.Errorf("invalid marks %q", sg.Marks)
+	}
+	marks := make([]Mark, len(sg.Marks))
+	for i, s := range sg.Marks {
+		m, err := parseMark(s)
+		if err != nil {
+			return nil, err
+		}
+		if m == Empty || slices.Contains(marks[:i], m) {
+			return nil, fmt.Errorf("invalid marks %q", sg.Marks)
+		}
+		marks[i] = m
+	}
+	names := sg.Players
+	if names == nil {
+		names = []string{sg.PlayerX, sg.PlayerO}
+	}
+	if len(names) != len(marks) {
+		return nil, fmt.Errorf("%d players for %d marks", len(names), len(marks))
+	}
+	if len(sg.Board) == 0 {
+		return nil, errors.New("missing board")
+	}
+
+	b := NewBoardMN(sg.Size, cols, sg.WinLen)
+	b.marks = marks
+	if err := b.UnmarshalJSON(sg.Board); err != nil {
+		return nil, err
+	}
+	if err := b.Validate(); err != nil {
+		return nil, err
+	}
+	played := make(map[int]bool, len(sg.History))
+	for i, sm := range sg.History {
+		m, err := parseMark(sm.Mark)
+		if err != nil {
+			return nil, err
+		}
+		if sm.Index < 0 || sm.Index >= len(b.cells) || b.At(sm.Index) != m || m == Empty {
+			return nil, fmt.Errorf("history move %d (%s@%d) does not match the board", i+1, sm.Mark, sm.Index)
+		}
+		if played[sm.Index] {
+			return nil, fmt.Errorf("history move %d (%s@%d) repeats a cell", i+1, sm.Mark, sm.Index)
+		}
+		if i > 0 && m != b.switchMark(b.history[i-1].Mark) {
+			return nil, fmt.Errorf("history move %d (%s@%d) is out of turn", i+1, sm.Mark, sm.Index)
+		}
+		played[sm.Index] = true
+		b.history = append(b.history, Move{Index: sm.Index, Mark: m})
+	}
+	current, err := parseMark(sg.Current)
+	if err != nil {
+		return nil, err
+	}
+	if !b.isMark(current) {
+		return nil, fmt.Errorf("invalid side to move %q", sg.Current)
+	}
+	if want, _ := b.InferTurn(); current != want { // checked by Validate
+		return nil, fmt.Errorf("%c is to move, not %c", want, current)
+	}
+	if last, ok := b.LastMove(); ok && b.switchMark(b.At(last)) != current {
+		return nil, fmt.Errorf("history ends with %c, but %c is to move", b.At(last), current)
+	}
+
+	players := make([]Player, len(names))
+	for i, name := range names {
+		players[i] = unboundPlayer{name}
+	}
+	return &Game{
+		board:   b,
+		players: players,
+		current: current,
+	}, nil
+}
+
+// SetPlayers replaces the game's players, in turn order, e.g. after
+// LoadGame. It panics if the number of players changes.
+func (g *Game) SetPlayers(players ...Player) {
+	if len(players) != len(g.players) {
+		panic(fmt.Sprintf("SetPlayers: got %d players, want %d", len(players), len(g.players)))
+	}
+	g.players = players
+}
+
+func parseMark(s string) (Mark, error) {
+	r := []rune(s)
+	if len(r) != 1 {
+		return Empty, fmt.Errorf("invalid mark %q", s)
+	}
+	return Mark(r[0]), nil
+}
+
+func playerName(p Player) string {
+	if p == nil {
+		return ""
+	}
+	return p.Name()
+}
+
+// unboundPlayer stands in for a player of a loaded game until SetPlayers is
+// called.
+type unboundPlayer struct{ name string }
+
+func (u unboundPlayer) Name() string { return u.name }
+
+func (u unboundPlayer) Move(ctx context.Context, b *Board, mark Mark) (int, error) {
+	return -1, fmt.Errorf("player %s is not bound; call SetPlayers", u.name)
+}
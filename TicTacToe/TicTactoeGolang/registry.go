@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PlayerFactory builds a player from the options given in a player spec.
+type PlayerFactory func(opts map[string]string) (Player, error)
+
+// PlayerRegistry maps player kinds to their factories for NewPlayerByName.
+// Every kind accepts a name option; add entries to support more kinds.
+var PlayerRegistry = map[string]PlayerFactory{
+	"human": func(opts map[string]string) (Player, error) {
+		if err := checkOpts(opts, "name"); err != nil {
+			return nil, err
+		}
+		return NewHuman(optName(opts, "Human")), nil
+	},
+	"random": func(opts map[string]string) (Player, error) {
+		if err := checkOpts(opts, "name", "seed"); err != nil {
+			return nil, err
+		}
+		name := optName(opts, "Random")
+		s, ok := opts["seed"]
+		if !ok {
+			return NewRandom(name), nil
+		}
+		seed, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid seed %q", s)
+		}
+		return NewRandomSeeded(name, seed), nil
+	},
+	"minimax": func(opts map[string]string) (Player, error) {
+		if err := checkOpts(opts, "name", "depth"); err != nil {
+			return nil, err
+		}
+		ai := NewMinimax(optName(opts, "Minimax"))
+		if s, ok := opts["depth"]; ok {
+			depth, err := strconv.Atoi(s)
+			if err != nil {
+				return nil, fmt.Errorf("invalid depth %q", s)
+			}
+			ai.MaxDepth = depth
+		}
+		return ai, nil
+	},
+	"deepening": func(opts map[string]string) (Player, error) {
+		if err := checkOpts(opts, "name", "budget"); err != nil {
+			return nil, err
+		}
+		budget := time.Second
+		if s, ok := opts["budget"]; ok {
+			d, err := time.ParseDuration(s)
+			if err != nil || d <= 0 {
+				return nil, fmt.Errorf("invalid budget %q", s)
+			}
+			budget = d
+		}
+		return NewIterativeDeepening(optName(opts, "Deepening"), budget), nil
+	},
+}
+
+// NewPlayerByName builds a player from a spec of the form kind or
+// kind:key=value,key=value, e.g. "random" or "minimax:depth=5,name=Bot".
+// The kind is looked up in PlayerRegistry.
+func NewPlayerByName(spec string) (Player, error) {
+	kind, rest, _ := strings.Cut(spec, ":")
+	factory, ok := PlayerRegistry[kind]
+	if !ok {
+		return nil, fmt.Errorf("player spec %q: unknown player %q", spec, kind)
+	}
+	opts := make(map[string]string)
+	if rest != "" {
+		for _, kv := range strings.Split(rest, ",") {
+			k, v, ok := strings.Cut(kv, "=")
+			if !ok || k == "" {
+				return nil, fmt.Errorf("player spec %q: option %q is not key=value", spec, kv)
+			}
+			opts[k] = v
+		}
+	}
+	p, err := factory(opts)
+	if err != nil {
+		return nil, fmt.Errorf("player spec %q: %w", spec, err)
+	}
+	return p, nil
+}
+
+// checkOpts errors if opts has a key not in allowed.
+func checkOpts(opts map[string]string, allowed ...string) error {
+	var unknown []string
+	for k := range opts {
+		known := false
+		for _, a := range allowed {
+			known = known || k == a
+		}
+		if !known {
+			unknown = append(unknown, k)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf("unknown option %q", unknown[0])
+	}
+	return nil
+}
+
+// optName returns the name option, or def if it is not set.
+func optName(opts map[string]string, def string) string {
+	if name, ok := opts["name"]; ok {
+		return name
+	}
+	return def
+}
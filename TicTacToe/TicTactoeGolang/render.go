@@ -0,0 +1,173 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Renderer draws a board as text for display.
+type Renderer interface {
+	Render(b *Board) string
+}
+
+// PlainRenderer draws boards with String.
+type PlainRenderer struct{}
+
+func (PlainRenderer) Render(b *Board) string { return b.String() }
+
+// NumberedRenderer draws boards with StringWithHints.
+type NumberedRenderer struct{}
+
+func (NumberedRenderer) Render(b *Board) string { return b.StringWithHints() }
+
+// ColorRenderer draws boards with ColorString.
+type ColorRenderer struct{}
+
+func (ColorRenderer) Render(b *Board) string { return b.ColorString() }
+
+// ANSI escape sequences used by Render, and by Game.Play to clear the
+// screen.
+const (
+	ansiReset = "\x1b[0m"
+	ansiX     = "\x1b[31m" // red
+	ansiO     = "\x1b[34m" // blue
+	ansiDim   = "\x1b[2m"
+	ansiClear = "\x1b[2J\x1b[H" // clear screen, cursor to top left
+)
+
+// ansiMarks colors the players' marks in turn order, cycling if there are
+// more players than colors.
+var ansiMarks = []string{ansiX, ansiO, "\x1b[32m", "\x1b[33m", "\x1b[35m", "\x1b[36m"}
+
+// Render draws the board like String but with each empty cell showing its
+// index, to help a human pick a move. If colored is true, marks and indices
+// are colored with ANSI escape codes; leave it false for output that is not a
+// terminal.
+func (b *Board) Render(colored bool) string {
+	width := len(strconv.Itoa(len(b.cells) - 1))
+	var sb strings.Builder
+	sep := "\n" + strings.Repeat("-", b.cols*(width+3)-3) + "\n"
+	for r := 0; r < b.rows; r++ {
+		for c := 0; c < b.cols; c++ {
+			idx := b.Index(r, c)
+			m := b.At(idx)
+			text, color := string(m), ansiDim
+			switch i := slices.Index(b.marks, m); {
+			case m == Empty:
+				text = strconv.Itoa(idx)
+			case i >= 0:
+				color = ansiMarks[i%len(ansiMarks)]
+			default:
+				color = ansiO // not a player's mark
+			}
+			text = padRight(text, width)
+			if colored {
+				text = color + text + ansiReset
+			}
+			sb.WriteString(text)
+			if c < b.cols-1 {
+				sb.WriteString(" | ")
+			}
+		}
+		if r < b.rows-1 {
+			sb.WriteString(sep)
+		}
+	}
+	return sb.String()
+}
+
+// ColorString is Render(true).
+func (b *Board) ColorString() string { return b.Render(true) }
+
+// StringWithHints is Render(false): the plain board with empty cells numbered.
+func (b *Board) StringWithHints() string { return b.Render(false) }
+
+// StringWithLastMove draws the board with the most recent move bracketed, as
+// in " X |[O]| . ", so it is easy to see what just changed.
+func (b *Board) StringWithLastMove() string {
+	last, _ := b.LastMove()
+	var sb strings.Builder
+	sep := "\n" + strings.Repeat("-", 4*b.cols-1) + "\n"
+	b.Each(func(idx, row, col int, m Mark) {
+		switch {
+		case col > 0:
+			sb.WriteString("|")
+		case row > 0:
+			sb.WriteString(sep)
+		}
+		left, right := " ", " "
+		if idx == last {
+			left, right = "[", "]"
+		}
+		sb.WriteString(left + string(m) + right)
+	})
+	return sb.String()
+}
+
+// DiffString draws after with the cells that differ from before bracketed, in
+// the style of StringWithLastMove. If the boards have different sizes there is
+// nothing to compare and it returns a note saying so.
+func DiffString(before, after *Board) string {
+	if before.rows != after.rows || before.cols != after.cols {
+		return fmt.Sprintf("boards differ in size: %dx%d and %dx%d", before.rows, before.cols, after.rows, after.cols)
+	}
+	var sb strings.Builder
+	sep := "\n" + strings.Repeat("-", 4*after.cols-1) + "\n"
+	after.Each(func(idx, row, col int, m Mark) {
+		switch {
+		case col > 0:
+			sb.WriteString("|")
+		case row > 0:
+			sb.WriteString(sep)
+		}
+		left, right := " ", " "
+		if before.At(idx) != m {
+			left, right = "[", "]"
+		}
+		sb.WriteString(left + string(m) + right)
+	})
+	return sb.String()
+}
+
+// RenderWithScores draws b with every free cell showing the score of toMove
+// playing there under perfect play, as Analyze scores it: +1 wins, +0 draws,
+// -1 loses. On a finished board the cells are drawn as in String. Perfect
+// play is only practical on small boards.
+func RenderWithScores(b *Board, toMove Mark) string {
+	texts := make([]string, len(b.cells))
+	for i, m := range b.cells {
+		texts[i] = string(m)
+	}
+	if _, over := b.Winner(); !over {
+		for _, sm := range NewMinimax("scores").rankMoves(context.Background(), b, toMove) {
+			texts[sm.move] = fmt.Sprintf("%+g", sm.score)
+		}
+	}
+	width := 0
+	for _, t := range texts {
+		width = max(width, utf8.RuneCountInString(t))
+	}
+	var sb strings.Builder
+	sep := "\n" + strings.Repeat("-", b.cols*(width+3)-3) + "\n"
+	b.Each(func(idx, row, col int, m Mark) {
+		switch {
+		case col > 0:
+			sb.WriteString(" | ")
+		case row > 0:
+			sb.WriteString(sep)
+		}
+		sb.WriteString(padRight(texts[idx], width))
+	})
+	return sb.String()
+}
+
+// padRight pads s with spaces to width characters. Marks may be any rune, so
+// characters are counted rather than bytes; s is returned as is if it is
+// already that wide.
+func padRight(s string, width int) string {
+	return s + strings.Repeat(" ", max(width-utf8.RuneCountInString(s), 0))
+}
@@ -0,0 +1,53 @@
+package main
+
+// Replay steps through a recorded 3x3 game one ply at a time, for reviewing
+// it move by move. The cursor starts on the empty board.
+type Replay struct {
+	moves  []Move
+	final  *Board // the board after every move
+	cursor int    // number of moves applied
+}
+
+// NewReplay returns a replay of moves, which must form a legal game as
+// ReplayFrom checks.
+func NewReplay(moves []Move) (*Replay, error) {
+	g, err := ReplayFrom(moves)
+	if err != nil {
+		return nil, err
+	}
+	return &Replay{moves: append([]Move(nil), moves...), final: g.board}, nil
+}
+
+// Ply returns how many moves have been applied at the cursor.
+func (r *Replay) Ply() int { return r.cursor }
+
+// Len returns the number of moves in the game.
+func (r *Replay) Len() int { return len(r.moves) }
+
+// Forward advances one move and returns the board after it. At the end of the
+// game it stays put and returns the final board and false.
+func (r *Replay) Forward() (*Board, bool) {
+	if r.cursor == len(r.moves) {
+		return r.Board(), false
+	}
+	r.cursor++
+	return r.Board(), true
+}
+
+// Back steps back one move and returns the board before it. At the start it
+// stays put and returns the empty board and false.
+func (r *Replay) Back() (*Board, bool) {
+	if r.cursor == 0 {
+		return r.Board(), false
+	}
+	r.cursor--
+	return r.Board(), true
+}
+
+// Board returns a new board holding the position at the cursor. The moves
+// after the cursor can be replayed on it with Redo.
+func (r *Replay) Board() *Board {
+	b := r.final.Clone()
+	_ = b.UndoTo(r.cursor) // the cursor never passes the last move
+	return b
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Scoreboard tallies results across the games of a session, keyed by player
+// name.
+type Scoreboard struct {
+	wins  map[string]int
+	names []string // first-seen order, keeps Summary stable
+	draws int
+}
+
+func NewScoreboard() *Scoreboard {
+	return &Scoreboard{wins: make(map[string]int)}
+}
+
+// Record adds the result of g, won by the player holding winner or drawn if
+// winner is Empty. Players are matched to marks in turn order, so games with
+// custom marks or more than two players count correctly. It is an error if
+// winner is no player's mark.
+func (s *Scoreboard) Record(g *Game, winner Mark) error {
+	i := slices.Index(g.board.marks, winner)
+	if winner != Empty && i < 0 {
+		return fmt.Errorf("record: %c is not a player's mark", winner)
+	}
+	for _, p := range g.players {
+		s.register(playerName(p))
+	}
+	if winner == Empty {
+		s.draws++
+		return nil
+	}
+	s.wins[playerName(g.players[i])]++
+	return nil
+}
+
+func (s *Scoreboard) register(name string) {
+	if _, ok := s.wins[name]; !ok {
+		s.wins[name] = 0
+		s.names = append(s.names, name)
+	}
+}
+
+// Wins returns the number of games won by the named player.
+func (s *Scoreboard) Wins(name string) int { return s.wins[name] }
+
+// Draws returns the number of drawn games.
+func (s *Scoreboard) Draws() int { return s.draws }
+
+// Summary renders the tally, e.g. "You: 1, AI: 2, Draws: 0".
+func (s *Scoreboard) Summary() string {
+	parts := make([]string, 0, len(s.names)+1)
+	for _, name := range s.names {
+		parts = append(parts, fmt.Sprintf("%s: %d", name, s.wins[name]))
+	}
+	parts = append(parts, fmt.Sprintf("Draws: %d", s.draws))
+	return strings.Join(parts, ", ")
+}
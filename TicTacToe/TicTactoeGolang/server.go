@@ -0,0 +1,145 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Server exposes games over HTTP with JSON bodies:
+//
+//	POST /game             create a game, returns its state including "id"
+//	POST /game/{id}/move   play {"index": n} for the side to move
+//	GET  /game/{id}        the board and whose turn it is
+//
+// Both sides move through the API; games live in memory only.
+type Server struct {
+	mu     sync.Mutex
+	games  map[string]*Game
+	nextID int
+	mux    *http.ServeMux
+}
+
+func NewServer() *Server {
+	s := &Server{games: make(map[string]*Game), mux: http.NewServeMux()}
+	s.mux.HandleFunc("/game", s.handleCreate)
+	s.mux.HandleFunc("/game/", s.handleGame)
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// gameState is the JSON view of a game. Turn is empty once the game is over;
+// Winner is empty while it runs or after a draw.
+type gameState struct {
+	ID     string `json:"id"`
+	Board  *Board `json:"board"`
+	Turn   string `json:"turn"`
+	Winner string `json:"winner"`
+	Over   bool   `json:"over"`
+}
+
+type moveRequest struct {
+	Index *int `json:"index"`
+}
+
+func (s *Server) handleCreate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextID++
+	id := strconv.Itoa(s.nextID)
+	g := NewGame(nil, nil)
+	s.games[id] = g
+	writeJSON(w, http.StatusCreated, stateOf(id, g))
+}
+
+// handleGame routes /game/{id} and /game/{id}/move.
+func (s *Server) handleGame(w http.ResponseWriter, r *http.Request) {
+	id, action, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/game/"), "/")
+	switch {
+	case action == "" && r.Method == http.MethodGet:
+		s.handleGet(w, id)
+	case action == "move" && r.Method == http.MethodPost:
+		s.handleMove(w, r, id)
+	case action == "" || action == "move":
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *Server) handleMove(w http.ResponseWriter, r *http.Request, id string) {
+	var req moveRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid JSON: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Index == nil {
+		http.Error(w, `missing "index"`, http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	g, ok := s.games[id]
+	if !ok {
+		http.Error(w, "game not found", http.StatusNotFound)
+		return
+	}
+	if stateOf(id, g).Over {
+		http.Error(w, "game is over", http.StatusConflict)
+		return
+	}
+	if err := g.board.CanMove(*req.Index); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := g.applyMove(*req.Index); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, stateOf(id, g))
+}
+
+func (s *Server) handleGet(w http.ResponseWriter, id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	g, ok := s.games[id]
+	if !ok {
+		http.Error(w, "game not found", http.StatusNotFound)
+		return
+	}
+	writeJSON(w, http.StatusOK, stateOf(id, g))
+}
+
+func stateOf(id string, g *Game) gameState {
+	st := gameState{ID: id, Board: g.board}
+	if w, over := g.IsOver(); over {
+		st.Over = true
+		if w != Empty {
+			st.Winner = string(w)
+		}
+	} else {
+		st.Turn = string(g.current)
+	}
+	return st
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(append(data, '\n'))
+}
@@ -0,0 +1,76 @@
+package main
+
+import "sync"
+
+// SyncBoard is a Board that is safe to share between goroutines. The methods
+// defined here take the lock; any other promoted Board method is not
+// synchronized and needs external locking.
+type SyncBoard struct {
+	*Board
+	mu sync.RWMutex
+}
+
+func NewSyncBoard(b *Board) *SyncBoard {
+	return &SyncBoard{Board: b}
+}
+
+func (s *SyncBoard) MakeMove(idx int, m Mark) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.Board.MakeMove(idx, m)
+}
+
+func (s *SyncBoard) Undo() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.Board.Undo()
+}
+
+func (s *SyncBoard) Redo() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.Board.Redo()
+}
+
+func (s *SyncBoard) Winner() (Mark, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.Board.Winner()
+}
+
+func (s *SyncBoard) WinnerLine() (Mark, []int, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.Board.WinnerLine()
+}
+
+func (s *SyncBoard) IsFull() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.Board.IsFull()
+}
+
+func (s *SyncBoard) AvailableMoves() []int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.Board.AvailableMoves()
+}
+
+func (s *SyncBoard) LegalMoveCount() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.Board.LegalMoveCount()
+}
+
+// Clone returns an unsynchronized snapshot of the board.
+func (s *SyncBoard) Clone() *Board {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.Board.Clone()
+}
+
+func (s *SyncBoard) String() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.Board.String()
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+// PlayerRecord is one player's tally over a tournament.
+type PlayerRecord struct {
+	Name   string
+	Wins   int
+	Losses int
+	Draws  int
+}
+
+// Games returns the number of games the player completed.
+func (r PlayerRecord) Games() int { return r.Wins + r.Losses + r.Draws }
+
+// WinRate returns the fraction of completed games won, 0 if none were played.
+func (r PlayerRecord) WinRate() float64 {
+	if r.Games() == 0 {
+		return 0
+	}
+	return float64(r.Wins) / float64(r.Games())
+}
+
+// TournamentResult collects the outcome of RunTournament.
+type TournamentResult struct {
+	Records []PlayerRecord // one per player, in the order given
+	Games   int            // games completed
+	Errors  int            // games abandoned because a player failed
+}
+
+// Standings returns the records ordered by win rate, best first. Ties keep
+// the original player order.
+func (t TournamentResult) Standings() []PlayerRecord {
+	out := append([]PlayerRecord(nil), t.Records...)
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].WinRate() > out[j].WinRate()
+	})
+	return out
+}
+
+// RunTournament plays a round robin: every pair of players meets for rounds
+// rounds, each round being one game with either player as X. Games are played
+// headlessly; a game that ends in an error is counted in Errors only. If ctx
+// is done the tournament stops, abandoning the game in progress, and the
+// games completed so far are returned.
+func RunTournament(ctx context.Context, players []Player, rounds int) TournamentResult {
+	res := TournamentResult{Records: make([]PlayerRecord, len(players))}
+	for i, p := range players {
+		res.Records[i].Name = p.Name()
+	}
+	for i := 0; i < len(players); i++ {
+		for j := i + 1; j < len(players); j++ {
+			for r := 0; r < rounds; r++ {
+				res.play(ctx, players, i, j)
+				res.play(ctx, players, j, i)
+				if ctx.Err() != nil {
+					return res
+				}
+			}
+		}
+	}
+	return res
+}
+
+// play runs one game with players[x] as X and players[o] as O, unless ctx is
+// already done. A game cut short by ctx is not counted.
+func (t *TournamentResult) play(ctx context.Context, players []Player, x, o int) {
+	if ctx.Err() != nil {
+		return
+	}
+	out, err := NewGame(players[x], players[o]).PlayHeadlessContext(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			t.Errors++
+		}
+		return
+	}
+	t.Games++
+	switch out.Winner {
+	case X:
+		t.Records[x].Wins++
+		t.Records[o].Losses++
+	case O:
+		t.Records[o].Wins++
+		t.Records[x].Losses++
+	default:
+		t.Records[x].Draws++
+		t.Records[o].Draws++
+	}
+}
+
+// SimulateAIvsAI plays games headless games between ai1 and ai2, alternating
+// who moves first, and prints a summary. Games that end in an error are not
+// counted.
+func SimulateAIvsAI(ai1, ai2 Player, games int) (wins1, wins2, draws int) {
+	for i := 0; i < games; i++ {
+		px, po := ai1, ai2
+		ai1IsX := i%2 == 0
+		if !ai1IsX {
+			px, po = ai2, ai1
+		}
+		out, err := NewGame(px, po).PlayHeadless()
+		if err != nil {
+			continue
+		}
+		switch {
+		case out.Winner == Empty:
+			draws++
+		case (out.Winner == X) == ai1IsX:
+			wins1++
+		default:
+			wins2++
+		}
+	}
+	fmt.Printf("%s: %d, %s: %d, Draws: %d\n", ai1.Name(), wins1, ai2.Name(), wins2, draws)
+	return wins1, wins2, draws
+}